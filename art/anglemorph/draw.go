@@ -1,6 +1,7 @@
 package anglemorph
 
 import (
+	"context"
 	"image/color"
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
@@ -18,8 +19,15 @@ type AngleMorph struct {
 }
 
 func (drawer AngleMorph) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
+	return drawer.DrawV2(context.Background(), ctx, art.Params{Card: card})
+}
+
+func (drawer AngleMorph) DrawV2(runCtx context.Context, ctx *canvas.Context, params art.Params) error {
+
+	card := params.Card
+	progress := params.Report()
 
-	seed := card.Attributes.Title + card.Attributes.Text + card.Attributes.CardTypeID + card.Attributes.FactionID + card.Attributes.Flavor
+	seed := params.SeedFrom(art.SeedFields...)
 
 	canvasWidth, canvasHeight := ctx.Size()
 
@@ -54,6 +62,8 @@ func (drawer AngleMorph) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		drawer.InterpolationSteps = makePointer(10)
 	}
 
+	progress.Stage("background")
+
 	// fill background
 	ctx.Push()
 	ctx.SetFillColor(cardBGColor)
@@ -65,6 +75,7 @@ func (drawer AngleMorph) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 	ctx.Fill()
 	ctx.Pop()
 
+	progress.Stage("lower morph")
 	first := &art.AngleMorph{
 		RNG:                rngGlobal,
 		Width:              width,
@@ -83,9 +94,14 @@ func (drawer AngleMorph) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 
 	first.Draw(ctx)
 
+	if err := runCtx.Err(); err != nil {
+		return err
+	}
+
 	y += height
 	height = canvasHeight - height + (canvasHeight * 0.1)
 
+	progress.Stage("upper morph")
 	second := &art.AngleMorph{
 		RNG:                rngGlobal,
 		Width:              width,
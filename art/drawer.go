@@ -1,6 +1,9 @@
 package art
 
 import (
+	"context"
+	"log"
+
 	"github.com/mangofeet/nrdb-go"
 	"github.com/tdewolff/canvas"
 )
@@ -11,10 +14,125 @@ func (df DrawerFunc) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 	return df(ctx, card)
 }
 
+// Drawer is the first version of the drawer interface, it draws to
+// the end once started. Every DrawerV2 is also a Drawer that draws
+// without a deadline or progress.
 type Drawer interface {
 	Draw(ctx *canvas.Context, card *nrdb.Printing) error
 }
 
+// DrawerV2 draws art to ctx that can be stopped part way through with
+// runCtx, telling params.Progress how it's getting on. When runCtx is
+// done it returns runCtx.Err() and what was drawn so far is
+// unfinished.
+//
+// The parameters are typed in two parts. The struct implementing
+// DrawerV2, e.g. netwalker.NetWalker or phungus.Entangler, is the
+// parameter set of its algorithm: colors, walker counts and limits,
+// with nil or zero meaning the default. It's built once and can draw
+// any number of cards. Params holds what changes from card to card.
+type DrawerV2 interface {
+	DrawV2(runCtx context.Context, ctx *canvas.Context, params Params) error
+}
+
+// Params are the parameters of a single card, the same for every
+// algorithm. The settings of the algorithm are the fields of the
+// drawer itself.
+type Params struct {
+	Card *nrdb.Printing
+
+	// Seed is what the random numbers start from, the drawer seeds
+	// from the card when it's nil. An empty seed is a seed like any
+	// other.
+	Seed *string
+
+	// Progress may be nil
+	Progress Progress
+
+	// Log is where the drawer logs to, the standard logger when nil
+	Log *log.Logger
+}
+
+// SeedFrom is params.Seed, or the given fields of the card when there
+// isn't one
+func (params Params) SeedFrom(fields ...string) string {
+	if params.Seed != nil {
+		return *params.Seed
+	}
+	return CardSeed(params.Card, fields)
+}
+
+// Report is where to send progress, which is never nil
+func (params Params) Report() Progress {
+	if params.Progress == nil {
+		return NoopProgress{}
+	}
+	return params.Progress
+}
+
+// Logger is params.Log, which is never nil
+func (params Params) Logger() *log.Logger {
+	if params.Log == nil {
+		return log.Default()
+	}
+	return params.Log
+}
+
+// Progress follows a drawer through the art. Walkers and rings are
+// counted by the drawer and there can be more than one round of each.
+type Progress interface {
+	// Stage is called as the drawer starts on a part of the art
+	Stage(name string)
+	// Walkers is called as every walker leaves the canvas
+	Walkers(done, total int)
+	// Rings is called as every set of tech rings is drawn
+	Rings(done, total int)
+}
+
+type NoopProgress struct {
+}
+
+func (NoopProgress) Stage(_ string) {
+}
+
+func (NoopProgress) Walkers(_, _ int) {
+}
+
+func (NoopProgress) Rings(_, _ int) {
+}
+
+// Upgrade returns drawer as a DrawerV2. Drawers that only have Draw
+// are stopped by runCtx before they start but not after, and report
+// nothing.
+func Upgrade(drawer Drawer) DrawerV2 {
+	if v2, ok := drawer.(DrawerV2); ok {
+		return v2
+	}
+	return drawerV1{drawer}
+}
+
+type drawerV1 struct {
+	Drawer
+}
+
+func (drawer drawerV1) DrawV2(runCtx context.Context, ctx *canvas.Context, params Params) error {
+	if err := runCtx.Err(); err != nil {
+		return err
+	}
+	return drawer.Draw(ctx, params.Card)
+}
+
+// Stopped is true once runCtx is done, it's cheap enough to check on
+// every walker step
+func Stopped(runCtx context.Context) bool {
+	select {
+	case <-runCtx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
 type NoopDrawer struct {
 }
 
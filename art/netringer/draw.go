@@ -1,6 +1,7 @@
 package netringer
 
 import (
+	"context"
 	"image/color"
 	"math"
 
@@ -13,11 +14,24 @@ import (
 type NetRinger struct {
 	Color, ColorBG                             *color.RGBA
 	AltColor1, AltColor2, AltColor3, AltColor4 *color.RGBA
+	Recorder                                   art.Recorder
+}
+
+func (drawer NetRinger) Record(rec art.Recorder) art.Drawer {
+	drawer.Recorder = rec
+	return drawer
 }
 
 func (drawer NetRinger) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
+	return drawer.DrawV2(context.Background(), ctx, art.Params{Card: card})
+}
+
+func (drawer NetRinger) DrawV2(runCtx context.Context, ctx *canvas.Context, params art.Params) error {
+
+	card := params.Card
+	progress := params.Report()
 
-	seed := card.Attributes.Title + card.Attributes.Text + card.Attributes.CardTypeID + card.Attributes.FactionID + card.Attributes.Flavor
+	seed := params.SeedFrom(art.SeedFields...)
 
 	canvasWidth, canvasHeight := ctx.Size()
 
@@ -39,6 +53,8 @@ func (drawer NetRinger) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		cardBGColor = *drawer.ColorBG
 	}
 
+	progress.Stage("background")
+
 	// fill background
 	ctx.Push()
 	ctx.SetFillColor(cardBGColor)
@@ -69,8 +85,20 @@ func (drawer NetRinger) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		AltColor2:   drawer.AltColor2,
 		AltColor3:   drawer.AltColor3,
 		AltColor4:   drawer.AltColor4,
+		Recorder:    drawer.Recorder,
+		Log:         params.Logger(),
+	}
+
+	if err := runCtx.Err(); err != nil {
+		return err
+	}
+
+	progress.Stage("rings")
+	if err := ringer.Draw(ctx); err != nil {
+		return err
 	}
+	progress.Rings(1, 1)
 
-	return ringer.Draw(ctx)
+	return nil
 
 }
@@ -1,9 +1,9 @@
 package netwalker
 
 import (
+	"context"
 	"fmt"
 	"image/color"
-	"log"
 	"math"
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
@@ -19,11 +19,25 @@ type NetWalker struct {
 	Color, ColorBG                                         *color.RGBA
 	WalkerColor1, WalkerColor2, WalkerColor3, WalkerColor4 *color.RGBA
 	GridColor1, GridColor2, GridColor3, GridColor4         *color.RGBA
+	Limits                                                 art.WalkerLimits
+	Recorder                                               art.Recorder
+}
+
+func (drawer NetWalker) Record(rec art.Recorder) art.Drawer {
+	drawer.Recorder = rec
+	return drawer
 }
 
 func (drawer NetWalker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
+	return drawer.DrawV2(context.Background(), ctx, art.Params{Card: card})
+}
+
+func (drawer NetWalker) DrawV2(runCtx context.Context, ctx *canvas.Context, params art.Params) error {
 
-	seed := card.Attributes.Title + card.Attributes.Text + card.Attributes.CardTypeID + card.Attributes.FactionID + card.Attributes.Flavor
+	card := params.Card
+	progress := params.Report()
+
+	seed := params.SeedFrom(art.SeedFields...)
 
 	canvasWidth, canvasHeight := ctx.Size()
 
@@ -83,6 +97,8 @@ func (drawer NetWalker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		cardBGColor = *drawer.ColorBG
 	}
 
+	progress.Stage("background")
+
 	// fill background
 	ctx.Push()
 	ctx.SetFillColor(cardBGColor)
@@ -270,15 +286,18 @@ func (drawer NetWalker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		walkers = append(walkers, &wlk)
 	}
 
-	for _, wlk := range walkers {
-		wlk.Draw(ctx)
-		for wlk.InBounds(ctx) {
-			wlk.Velocity()
-			wlk.Move()
-			wlk.Draw(ctx)
+	progress.Stage("walkers")
+	walks := drawer.Limits.Start()
+	for i, wlk := range walkers {
+		if err := walks.Walk(runCtx, ctx, wlk, drawer.Recorder); err != nil {
+			return err
 		}
+		progress.Walkers(i+1, len(walkers))
+	}
+	params.Logger().Printf("finished %d walkers", len(walkers))
+	if summary := walks.Summary(); summary != "" {
+		params.Logger().Println(summary)
 	}
-	log.Printf("finished %d walkers", len(walkers))
 
 	return nil
 }
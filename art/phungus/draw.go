@@ -1,9 +1,9 @@
 package phungus
 
 import (
+	"context"
 	"fmt"
 	"image/color"
-	"log"
 	"math"
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
@@ -20,11 +20,25 @@ type Entangler struct {
 	WalkerColor1, WalkerColor2, WalkerColor3, WalkerColor4 *color.RGBA
 	GridColor1, GridColor2, GridColor3, GridColor4         *color.RGBA
 	RingColor1, RingColor2, RingColor3, RingColor4         *color.RGBA
+	Limits                                                 art.WalkerLimits
+	Recorder                                               art.Recorder
+}
+
+func (drawer Entangler) Record(rec art.Recorder) art.Drawer {
+	drawer.Recorder = rec
+	return drawer
 }
 
 func (drawer Entangler) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
+	return drawer.DrawV2(context.Background(), ctx, art.Params{Card: card})
+}
+
+func (drawer Entangler) DrawV2(runCtx context.Context, ctx *canvas.Context, params art.Params) error {
 
-	seed := card.Attributes.Title + card.Attributes.Text + card.Attributes.CardTypeID + card.Attributes.FactionID + card.Attributes.Flavor
+	card := params.Card
+	progress := params.Report()
+
+	seed := params.SeedFrom(art.SeedFields...)
 
 	canvasWidth, canvasHeight := ctx.Size()
 
@@ -114,6 +128,8 @@ func (drawer Entangler) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 	ringStrokeMin := canvasWidth * 0.08
 	ringStrokeMax := canvasWidth * 0.18
 
+	progress.Stage("background")
+
 	// fill background
 	ctx.Push()
 	ctx.SetFillColor(cardBGColor)
@@ -318,6 +334,15 @@ func (drawer Entangler) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		walkers = append(walkers, &wlk)
 	}
 
+	// progress counts the four sets of rings
+	ringsDone := 0
+	ringDone := func() error {
+		ringsDone++
+		progress.Rings(ringsDone, 4)
+		return runCtx.Err()
+	}
+
+	progress.Stage("rings")
 	ringSequence := int64(9999)
 	// rings under the walkers
 	ringSequence++
@@ -339,10 +364,16 @@ func (drawer Entangler) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		// AltColor3:    &canvas.Red,
 		// AltColor4:    &canvas.Red,
 		OverlayColor: &canvas.Transparent,
+		Recorder:     drawer.Recorder,
+		Log:          params.Logger(),
 	}).Draw(ctx)
+	if err := ringDone(); err != nil {
+		return err
+	}
 
+	progress.Stage("walkers")
+	walks := drawer.Limits.Start()
 	for i, wlk := range walkers {
-		wlk.Draw(ctx)
 		if i == (len(walkers)/4)*3 {
 			ringSequence++
 			(art.TechRing{
@@ -363,16 +394,21 @@ func (drawer Entangler) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 				// AltColor3:    &canvas.Green,
 				// AltColor4:    &canvas.Green,
 				OverlayColor: &canvas.Transparent,
+				Recorder:     drawer.Recorder,
+				Log:          params.Logger(),
 			}).Draw(ctx)
+			if err := ringDone(); err != nil {
+				return err
+			}
 
 		}
-		for wlk.InBounds(ctx) {
-			wlk.Velocity()
-			wlk.Move()
-			wlk.Draw(ctx)
+		if err := walks.Walk(runCtx, ctx, wlk, drawer.Recorder); err != nil {
+			return err
 		}
+		progress.Walkers(i+1, len(walkers))
 	}
 
+	progress.Stage("rings")
 	// rings over the walkers
 	ringSequence++
 	(art.TechRing{
@@ -394,7 +430,12 @@ func (drawer Entangler) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		// AltColor3:    &canvas.Blue,
 		// AltColor4:    &canvas.Blue,
 		OverlayColor: &canvas.Transparent,
+		Recorder:     drawer.Recorder,
+		Log:          params.Logger(),
 	}).Draw(ctx)
+	if err := ringDone(); err != nil {
+		return err
+	}
 
 	ringSequence++
 	(art.TechRing{
@@ -411,9 +452,17 @@ func (drawer Entangler) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		AltColor3:    &overlayRingColor,
 		AltColor4:    &overlayRingColor,
 		OverlayColor: &overlayRingColor,
+		Recorder:     drawer.Recorder,
+		Log:          params.Logger(),
 	}).Draw(ctx)
+	if err := ringDone(); err != nil {
+		return err
+	}
 
-	log.Printf("finished %d walkers", len(walkers))
+	params.Logger().Printf("finished %d walkers", len(walkers))
+	if summary := walks.Summary(); summary != "" {
+		params.Logger().Println(summary)
+	}
 
 	return nil
 }
@@ -0,0 +1,21 @@
+package art
+
+import "image"
+
+// Recorder follows the art while it is drawn, to capture the frames of
+// an animation
+type Recorder interface {
+	// Step is called after every walker step
+	Step()
+	// Ring is called as every ring of a TechRing is revealed, inside
+	// out. The rings are only drawn on the canvas once they are all
+	// done, img has all of them and the part within radius of x, y
+	// is what has been drawn so far.
+	Ring(img image.Image, x, y, radius float64)
+}
+
+// Recordable is a Drawer that can tell a Recorder how it is getting on
+type Recordable interface {
+	Drawer
+	Record(rec Recorder) Drawer
+}
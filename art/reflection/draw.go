@@ -1,15 +1,16 @@
 package reflection
 
 import (
+	"context"
 	"image"
 	"image/color"
 	"image/draw"
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/internal/prng"
+	"github.com/mangofeet/netrunner-alt-gen/layout"
 	"github.com/mangofeet/nrdb-go"
 	"github.com/tdewolff/canvas"
-	"github.com/tdewolff/canvas/renderers/rasterizer"
 )
 
 type Reflection struct {
@@ -21,8 +22,15 @@ type Reflection struct {
 }
 
 func (drawer Reflection) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
+	return drawer.DrawV2(context.Background(), ctx, art.Params{Card: card})
+}
+
+func (drawer Reflection) DrawV2(runCtx context.Context, ctx *canvas.Context, params art.Params) error {
+
+	card := params.Card
+	progress := params.Report()
 
-	seed := card.Attributes.Title + card.Attributes.Text + card.Attributes.CardTypeID + card.Attributes.FactionID + card.Attributes.Flavor
+	seed := params.SeedFrom(art.SeedFields...)
 
 	canvasWidth, canvasHeight := ctx.Size()
 
@@ -54,6 +62,8 @@ func (drawer Reflection) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		drawer.InterpolationSteps = makePointer(10)
 	}
 
+	progress.Stage("background")
+
 	// fill background
 	ctx.Push()
 	ctx.SetFillColor(cardBGColor)
@@ -73,6 +83,7 @@ func (drawer Reflection) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 	baseCnv := canvas.New(canvasWidth, canvasHeight)
 	baseCtx := canvas.NewContext(baseCnv)
 
+	progress.Stage("lower morph")
 	first := &art.AngleMorph{
 		RNG:                rngGlobal,
 		Width:              width,
@@ -91,9 +102,14 @@ func (drawer Reflection) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 
 	first.Draw(baseCtx)
 
+	if err := runCtx.Err(); err != nil {
+		return err
+	}
+
 	y += height
 	height = canvasHeight - height + (canvasHeight * 0.1)
 
+	progress.Stage("upper morph")
 	second := &art.AngleMorph{
 		RNG:                rngGlobal,
 		Width:              width,
@@ -114,8 +130,11 @@ func (drawer Reflection) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 
 	second.Draw(baseCtx)
 
-	baseImg := rasterizer.Draw(baseCnv, canvas.DPMM(1), canvas.DefaultColorSpace)
+	if err := runCtx.Err(); err != nil {
+		return err
+	}
 
+	progress.Stage("mask")
 	maskCnv := canvas.New(canvasWidth, canvasHeight)
 	maskCtx := canvas.NewContext(maskCnv)
 
@@ -138,21 +157,29 @@ func (drawer Reflection) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 
 	mask.Draw(maskCtx)
 
-	maskImg := rasterizer.Draw(maskCnv, canvas.DPMM(1), canvas.DefaultColorSpace)
+	if err := runCtx.Err(); err != nil {
+		return err
+	}
 
-	// invert the mask image
-	for i, pxl := range maskImg.Pix {
-		if pxl == 0 {
-			maskImg.Pix[i] = 255
-		} else {
-			maskImg.Pix[i] = 0
+	// on cards too big to rasterize whole this only runs as each band
+	// of the card is written
+	final := layout.Tile(layout.RasterSize(baseCnv), func(dst *image.RGBA) {
+		baseImg := layout.RasterizeRows(baseCnv, dst.Rect)
+		maskImg := layout.RasterizeRows(maskCnv, dst.Rect)
+
+		// invert the mask image
+		for i, pxl := range maskImg.Pix {
+			if pxl == 0 {
+				maskImg.Pix[i] = 255
+			} else {
+				maskImg.Pix[i] = 0
+			}
 		}
-	}
 
-	final := image.NewRGBA(image.Rect(0, 0, int(canvasWidth), int(canvasHeight)))
-	draw.DrawMask(final, final.Bounds(), baseImg, image.Point{}, maskImg, image.Point{}, draw.Over)
+		draw.DrawMask(dst, dst.Rect, baseImg, dst.Rect.Min, maskImg, dst.Rect.Min, draw.Over)
+	})
 
-	ctx.RenderImage(final, canvas.Identity)
+	ctx.RenderImage(final, layout.ImageMatrix())
 
 	// var walkers []*art.Walker
 
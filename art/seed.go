@@ -0,0 +1,42 @@
+package art
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// SeedFields are the card fields a seed can be made from, in the
+// order they're joined
+var SeedFields = []string{"title", "text", "type", "faction", "flavor"}
+
+// CardSeed joins the fields of card the art is seeded with. They're
+// always joined in the order of SeedFields, so the default of every
+// field gives the seed the drawers have always used.
+func CardSeed(card *nrdb.Printing, fields []string) string {
+	values := map[string]string{
+		"title":   card.Attributes.Title,
+		"text":    card.Attributes.Text,
+		"type":    card.Attributes.CardTypeID,
+		"faction": card.Attributes.FactionID,
+		"flavor":  card.Attributes.Flavor,
+	}
+
+	seed := ""
+	for _, field := range SeedFields {
+		if slices.Contains(fields, field) {
+			seed += values[field]
+		}
+	}
+	return seed
+}
+
+// VariantSeed is the seed of a numbered variant of the art, variant 0
+// is seed itself
+func VariantSeed(seed string, variant int) string {
+	if variant == 0 {
+		return seed
+	}
+	return fmt.Sprintf("%s#%d", seed, variant)
+}
@@ -0,0 +1,55 @@
+package art
+
+import (
+	"testing"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+func TestCardSeed(t *testing.T) {
+
+	card := &nrdb.Printing{}
+	card.Attributes = &nrdb.PrintingAttributes{
+		CardAttributes: nrdb.CardAttributes{
+			Title:      "Hedge Fund",
+			CardTypeID: "operation",
+			FactionID:  "neutral_corp",
+		},
+		Text:   "Gain 9[credit].",
+		Flavor: "Hedge your bets.",
+	}
+
+	tests := []struct {
+		fields []string
+		want   string
+	}{
+		// every field is the seed the drawers always used
+		{fields: SeedFields, want: "Hedge FundGain 9[credit].operationneutral_corpHedge your bets."},
+		{fields: []string{"title"}, want: "Hedge Fund"},
+		// fields are joined in the order of SeedFields
+		{fields: []string{"faction", "title"}, want: "Hedge Fundneutral_corp"},
+		{fields: []string{"flavor", "flavor"}, want: "Hedge your bets."},
+		{fields: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := CardSeed(card, tt.fields); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.fields, got, tt.want)
+		}
+	}
+}
+
+func TestVariantSeed(t *testing.T) {
+	if got := VariantSeed("Hedge Fund", 0); got != "Hedge Fund" {
+		t.Errorf("variant 0 changed the seed to %q", got)
+	}
+
+	seen := map[string]int{}
+	for variant := 0; variant < 100; variant++ {
+		seed := VariantSeed("Hedge Fund", variant)
+		if other, ok := seen[seed]; ok {
+			t.Fatalf("variants %d and %d share the seed %q", other, variant, seed)
+		}
+		seen[seed] = variant
+	}
+}
@@ -9,8 +9,8 @@ import (
 	"math"
 
 	"github.com/mangofeet/netrunner-alt-gen/internal/prng"
+	"github.com/mangofeet/netrunner-alt-gen/layout"
 	"github.com/tdewolff/canvas"
-	"github.com/tdewolff/canvas/renderers/rasterizer"
 )
 
 type TechRing struct {
@@ -23,16 +23,26 @@ type TechRing struct {
 	Color                                      color.RGBA
 	AltColor1, AltColor2, AltColor3, AltColor4 *color.RGBA
 	OverlayColor                               *color.RGBA
+	Recorder                                   Recorder
+	// Log is where the rings are logged, the standard logger when nil
+	Log *log.Logger
+}
+
+func (drawer TechRing) logger() *log.Logger {
+	if drawer.Log == nil {
+		return log.Default()
+	}
+	return drawer.Log
 }
 
 func (drawer TechRing) log(args ...interface{}) {
 	args = append([]interface{}{fmt.Sprintf("techring %d:", drawer.RNG.Sequence())}, args...)
-	log.Println(args...)
+	drawer.logger().Println(args...)
 }
 
 func (drawer TechRing) logf(format string, args ...interface{}) {
 	format = fmt.Sprintf("techring %d: %s", drawer.RNG.Sequence(), format)
-	log.Printf(format, args...)
+	drawer.logger().Printf(format, args...)
 }
 
 func (drawer TechRing) Draw(ctx *canvas.Context) error {
@@ -53,16 +63,11 @@ func (drawer TechRing) Draw(ctx *canvas.Context) error {
 		Angle:       drawer.Angle,
 	}
 
-	if err := circ.Draw(ringBaseCtx); err != nil {
+	rings, err := circ.Draw(ringBaseCtx)
+	if err != nil {
 		return err
 	}
 
-	drawer.log("rasterizing ring")
-	ringBaseImg := rasterizer.Draw(ringBaseCnv, canvas.DPMM(1), canvas.DefaultColorSpace)
-
-	overlayCnv := canvas.New(canvasWidth, canvasHeight)
-	overlayCtx := canvas.NewContext(ringBaseCnv)
-
 	overlayColor := color.RGBA{
 		R: 0xff,
 		G: 0xff,
@@ -73,6 +78,11 @@ func (drawer TechRing) Draw(ctx *canvas.Context) error {
 		overlayColor = *drawer.OverlayColor
 	}
 
+	// the overlay used to go on the base ring after that was
+	// rasterized, so it never showed. It's still drawn, on its own, to
+	// use the same random numbers and keep the art as it was.
+	overlayCtx := canvas.NewContext(canvas.New(canvasWidth, canvasHeight))
+
 	circOverlay := techCircleDrawer{
 		RNG:         drawer.RNG,
 		X:           drawer.X,
@@ -89,24 +99,10 @@ func (drawer TechRing) Draw(ctx *canvas.Context) error {
 		SegmentArcMax: 15,
 	}
 
-	if err := circOverlay.Draw(overlayCtx); err != nil {
+	if _, err := circOverlay.Draw(overlayCtx); err != nil {
 		return err
 	}
 
-	drawer.log("rasterizing overlay ring")
-	ringOverlayImg := rasterizer.Draw(overlayCnv, canvas.DPMM(1), canvas.DefaultColorSpace)
-
-	ringCnv := canvas.New(canvasWidth, canvasHeight)
-	ringCtx := canvas.NewContext(ringCnv)
-
-	drawer.log("rendering base ring")
-	ringCtx.RenderImage(ringBaseImg, canvas.Identity)
-	drawer.log("rendering overlay ring")
-	ringCtx.RenderImage(ringOverlayImg, canvas.Identity)
-
-	drawer.log("rasterizing combined ring and overlay images")
-	ringImg := rasterizer.Draw(ringCnv, canvas.DPMM(1), canvas.DefaultColorSpace)
-
 	maskCnv := canvas.New(canvasWidth, canvasHeight)
 	maskCtx := canvas.NewContext(maskCnv)
 
@@ -126,31 +122,53 @@ func (drawer TechRing) Draw(ctx *canvas.Context) error {
 		SegmentArcMax: 5,
 	}
 
-	if err := circBlanker.Draw(maskCtx); err != nil {
+	if _, err := circBlanker.Draw(maskCtx); err != nil {
 		return err
 	}
 
-	drawer.log("rasterizing mask ring")
-	maskImg := rasterizer.Draw(maskCnv, canvas.DPMM(1), canvas.DefaultColorSpace)
-
-	// invert the mask image
-	for i, pxl := range maskImg.Pix {
-		if pxl == 0 {
-			maskImg.Pix[i] = 255
-		} else {
-			maskImg.Pix[i] = 0
+	// big rasters are put together a band at a time, as the card is
+	// written
+	drawer.log("rasterizing rings")
+	ringsFinal := layout.Tile(layout.RasterSize(ringBaseCnv), func(dst *image.RGBA) {
+		ringImg := layout.RasterizeRows(ringBaseCnv, dst.Rect)
+		maskImg := layout.RasterizeRows(maskCnv, dst.Rect)
+
+		// invert the mask image
+		for i, pxl := range maskImg.Pix {
+			if pxl == 0 {
+				maskImg.Pix[i] = 255
+			} else {
+				maskImg.Pix[i] = 0
+			}
 		}
-	}
 
-	ringsFinal := image.NewRGBA(image.Rect(0, 0, int(canvasWidth), int(canvasHeight)))
-	draw.DrawMask(ringsFinal, ringsFinal.Bounds(), ringImg, image.Point{}, maskImg, image.Point{}, draw.Over)
+		draw.DrawMask(dst, dst.Rect, ringImg, dst.Rect.Min, maskImg, dst.Rect.Min, draw.Over)
+	})
+
+	drawer.record(ringsFinal, rings)
 
 	drawer.log("rendering final rings")
-	ctx.RenderImage(ringsFinal, canvas.Identity)
+	ctx.RenderImage(ringsFinal, layout.ImageMatrix())
 
 	return nil
 }
 
+// record reveals the finished rings to the recorder one base ring at a
+// time, the last one shows everything, overlay and all
+func (drawer TechRing) record(img image.Image, rings []circleRing) {
+	if drawer.Recorder == nil {
+		return
+	}
+
+	for i, ring := range rings {
+		radius := math.Inf(1)
+		if i < len(rings)-1 {
+			radius = ring.radius + ring.strokeWidth()/2
+		}
+		drawer.Recorder.Ring(img, drawer.X, drawer.Y, radius)
+	}
+}
+
 type colorGetter func(rng prng.Generator) (color.Color, error)
 
 type techCircleDrawer struct {
@@ -191,7 +209,16 @@ type circleRing struct {
 	rotation float64
 }
 
-func (drawer techCircleDrawer) Draw(ctx *canvas.Context) error {
+func (ring circleRing) strokeWidth() float64 {
+	var width float64
+	for _, seg := range ring.segments {
+		width = math.Max(width, seg.strokeWidth)
+	}
+	return width
+}
+
+// Draw draws the rings outside in and returns them inside out
+func (drawer techCircleDrawer) Draw(ctx *canvas.Context) ([]circleRing, error) {
 	radius := drawer.RadiusStart
 
 	var rings []circleRing
@@ -224,7 +251,7 @@ func (drawer techCircleDrawer) Draw(ctx *canvas.Context) error {
 		strokeWidth := math.Min(drawer.StrokeMax, math.Min(math.Max(drawer.StrokeMin, strokeWidthRand), radius*0.5))
 		thisColor, err := drawer.GetColor(drawer.RNG)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// darkFactor := drawer.Radius / (drawer.Radius - radius) * 0.7
@@ -316,10 +343,9 @@ func (drawer techCircleDrawer) Draw(ctx *canvas.Context) error {
 
 			if seg.shouldRender() {
 				ctx.Push()
-				ctx.SetStrokeColor(seg.strokeColor)
-				ctx.SetStrokeWidth(seg.strokeWidth)
-				ctx.SetFillColor(canvas.Transparent)
-				ctx.DrawPath(x, y, path)
+				ctx.SetFillColor(seg.strokeColor)
+				ctx.SetStrokeColor(canvas.Transparent)
+				ctx.DrawPath(x, y, arcOutline(ring.radius, seg.start, seg.end, seg.strokeWidth))
 				ctx.Pop()
 			}
 
@@ -329,8 +355,35 @@ func (drawer techCircleDrawer) Draw(ctx *canvas.Context) error {
 
 	}
 
-	return nil
+	return rings, nil
+
+}
+
+// arcOutline is the outline of an arc of radius stroked width wide
+// with butt caps, relative to where the arc starts. It's the ring
+// sector between the inner and outer edge of the stroke, drawn as a
+// fill because the stroker in canvas panics on some of these arcs and
+// draws nothing for others.
+func arcOutline(radius, start, end, width float64) *canvas.Path {
+	outer := radius + width/2
+	inner := math.Max(radius-width/2, 0)
+
+	// the center of the arc, the start of the arc being the origin
+	startRad, endRad := start*math.Pi/180, end*math.Pi/180
+	cx, cy := -radius*math.Cos(startRad), -radius*math.Sin(startRad)
+
+	path := &canvas.Path{}
+	path.MoveTo(cx+outer*math.Cos(startRad), cy+outer*math.Sin(startRad))
+	path.Arc(outer, outer, 0, start, end)
+	if inner == 0 {
+		path.LineTo(cx, cy)
+	} else {
+		path.LineTo(cx+inner*math.Cos(endRad), cy+inner*math.Sin(endRad))
+		path.Arc(inner, inner, 0, end, start)
+	}
+	path.Close()
 
+	return path
 }
 
 func reverse[T any](slc []T) []T {
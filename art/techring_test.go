@@ -0,0 +1,93 @@
+package art
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+)
+
+// drawArc rasterizes an arc the way the tech ring places its segments,
+// either stroked like it used to be or filled from arcOutline
+func drawArc(radius, start, end, width float64, stroke bool) *image.RGBA {
+	cnv := canvas.New(100, 100)
+	ctx := canvas.NewContext(cnv)
+
+	x, y := 50+radius, 50.0
+	if stroke {
+		path := &canvas.Path{}
+		path.Arc(radius, radius, 0.1, start, end)
+		ctx.SetStrokeColor(canvas.Black)
+		ctx.SetStrokeWidth(width)
+		ctx.SetFillColor(canvas.Transparent)
+		ctx.DrawPath(x, y, path)
+	} else {
+		ctx.SetFillColor(canvas.Black)
+		ctx.SetStrokeColor(canvas.Transparent)
+		ctx.DrawPath(x, y, arcOutline(radius, start, end, width))
+	}
+
+	return rasterizer.Draw(cnv, canvas.DPMM(10), canvas.DefaultColorSpace)
+}
+
+// coverage is the drawn area in mm², pixels count by their alpha
+func coverage(img *image.RGBA) float64 {
+	var sum int
+	for i := 3; i < len(img.Pix); i += 4 {
+		sum += int(img.Pix[i])
+	}
+	return float64(sum) / 255 / 100
+}
+
+// TestArcOutlineMatchesStroke compares the filled outlines against
+// stroked arcs. Where the stroker manages they may only differ in the
+// antialiasing along the edges, and every outline covers the area of
+// its ring sector, including the arcs the stroker drops or panics on.
+func TestArcOutlineMatchesStroke(t *testing.T) {
+
+	tests := []struct {
+		radius, start, end, width float64
+		// the stroker in canvas draws nothing for these
+		strokeFails bool
+	}{
+		{radius: 10, start: 0, end: 90, width: 2},
+		{radius: 30, start: -30, end: 30, width: 0.5},
+		{radius: 40, start: 180, end: 181, width: 4},
+		{radius: 5, start: 90, end: 270, width: 8},
+		{radius: 20, start: 45, end: 200, width: 5},
+		{radius: 20, start: 45, end: 300, width: 5, strokeFails: true},
+		{radius: 15, start: 10, end: 350, width: 1, strokeFails: true},
+	}
+
+	for _, tt := range tests {
+		filled := drawArc(tt.radius, tt.start, tt.end, tt.width, false)
+
+		outer, inner := tt.radius+tt.width/2, math.Max(tt.radius-tt.width/2, 0)
+		sector := math.Pi * (outer*outer - inner*inner) * (tt.end - tt.start) / 360
+		if got := coverage(filled); math.Abs(got-sector) > sector*0.01 {
+			t.Errorf("arc %+v covers %.2fmm², want %.2fmm²", tt, got, sector)
+		}
+
+		if tt.strokeFails {
+			continue
+		}
+
+		stroked := drawArc(tt.radius, tt.start, tt.end, tt.width, true)
+		if a, b := coverage(stroked), coverage(filled); math.Abs(a-b) > a*0.001 {
+			t.Errorf("arc %+v: stroked covers %.2fmm², filled %.2fmm²", tt, a, b)
+		}
+		// the stroker flattens the edges within a fraction of a pixel,
+		// the outlines follow the arcs themselves
+		var off int
+		for i := 3; i < len(stroked.Pix); i += 4 {
+			if a, b := int(stroked.Pix[i]), int(filled.Pix[i]); max(a-b, b-a) > 128 {
+				off++
+			}
+		}
+		if area := coverage(stroked) * 100; float64(off) > area*0.01 {
+			t.Errorf("arc %+v: %d pixels differ by more than half, in %.0f", tt, off, area)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package tracker
 
 import (
+	"context"
 	"image/color"
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
@@ -13,11 +14,24 @@ type Tracker struct {
 	Color, ColorBG                                 *color.RGBA
 	RingColor1, RingColor2, RingColor3, RingColor4 *color.RGBA
 	OverlayRingColor                               *color.RGBA
+	Recorder                                       art.Recorder
+}
+
+func (drawer Tracker) Record(rec art.Recorder) art.Drawer {
+	drawer.Recorder = rec
+	return drawer
 }
 
 func (drawer Tracker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
+	return drawer.DrawV2(context.Background(), ctx, art.Params{Card: card})
+}
 
-	seed := card.Attributes.Title
+func (drawer Tracker) DrawV2(runCtx context.Context, ctx *canvas.Context, params art.Params) error {
+
+	card := params.Card
+	progress := params.Report()
+
+	seed := params.SeedFrom("title")
 
 	canvasWidth, canvasHeight := ctx.Size()
 
@@ -68,6 +82,8 @@ func (drawer Tracker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 	ringStrokeMin := canvasWidth * 0.04
 	ringStrokeMax := canvasWidth * 0.08
 
+	progress.Stage("background")
+
 	// fill background
 	ctx.Push()
 	ctx.SetFillColor(cardBGColor)
@@ -79,6 +95,15 @@ func (drawer Tracker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 	ctx.Fill()
 	ctx.Pop()
 
+	// progress counts the four sets of rings
+	ringsDone := 0
+	ringDone := func() error {
+		ringsDone++
+		progress.Rings(ringsDone, 4)
+		return runCtx.Err()
+	}
+
+	progress.Stage("rings")
 	ringSequence := int64(2)
 	// rings under the walkers
 	ringSequence++
@@ -96,7 +121,12 @@ func (drawer Tracker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		AltColor3:    ringColor3,
 		AltColor4:    ringColor4,
 		OverlayColor: &canvas.Transparent,
+		Recorder:     drawer.Recorder,
+		Log:          params.Logger(),
 	}).Draw(ctx)
+	if err := ringDone(); err != nil {
+		return err
+	}
 
 	ringSequence++
 	(art.TechRing{
@@ -113,7 +143,12 @@ func (drawer Tracker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		AltColor3:    ringColor3,
 		AltColor4:    ringColor4,
 		OverlayColor: &canvas.Transparent,
+		Recorder:     drawer.Recorder,
+		Log:          params.Logger(),
 	}).Draw(ctx)
+	if err := ringDone(); err != nil {
+		return err
+	}
 
 	// rings over the walkers
 	ringSequence++
@@ -131,7 +166,12 @@ func (drawer Tracker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		AltColor3:    ringColor3,
 		AltColor4:    ringColor4,
 		OverlayColor: &canvas.Transparent,
+		Recorder:     drawer.Recorder,
+		Log:          params.Logger(),
 	}).Draw(ctx)
+	if err := ringDone(); err != nil {
+		return err
+	}
 
 	ringSequence++
 	(art.TechRing{
@@ -148,7 +188,12 @@ func (drawer Tracker) Draw(ctx *canvas.Context, card *nrdb.Printing) error {
 		AltColor3:    &overlayRingColor,
 		AltColor4:    &overlayRingColor,
 		OverlayColor: &overlayRingColor,
+		Recorder:     drawer.Recorder,
+		Log:          params.Logger(),
 	}).Draw(ctx)
+	if err := ringDone(); err != nil {
+		return err
+	}
 
 	return nil
 }
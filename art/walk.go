@@ -0,0 +1,153 @@
+package art
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tdewolff/canvas"
+)
+
+// WalkerLimits stop walkers that never leave the canvas, a walker
+// whose velocity cancels out or a grid walker going back and forth
+// would otherwise hang the card. The zero value has no limits.
+type WalkerLimits struct {
+	// MaxSteps is the most steps a single walker takes
+	MaxSteps int
+
+	// a walker that is less than StallDistance from where it was
+	// StallWindow steps ago has stalled
+	StallWindow   int
+	StallDistance float64
+
+	// StepBudget and TimeBudget are shared by all the walkers of a
+	// card, the walkers left when either runs out aren't drawn
+	StepBudget int
+	TimeBudget time.Duration
+}
+
+// reasons walkers are stopped early, in the order they're logged
+const (
+	stopMaxSteps   = "at the step limit"
+	stopStalled    = "stalled"
+	stopStepBudget = "out of step budget"
+	stopTimeBudget = "out of time budget"
+)
+
+// Walks runs the walkers of a card within its limits, counting the
+// ones stopped early
+type Walks struct {
+	limits   WalkerLimits
+	deadline time.Time
+
+	walkers, steps int
+	stopped        map[string]int
+}
+
+// Start starts the budgets of a card
+func (limits WalkerLimits) Start() *Walks {
+	walks := &Walks{
+		limits:  limits,
+		stopped: map[string]int{},
+	}
+	if limits.TimeBudget > 0 {
+		walks.deadline = time.Now().Add(limits.TimeBudget)
+	}
+	return walks
+}
+
+// Walk moves wlk until it leaves the canvas or a limit stops it, rec
+// is told about every step and may be nil
+func (walks *Walks) Walk(runCtx context.Context, ctx *canvas.Context, wlk *Walker, rec Recorder) error {
+	walks.walkers++
+
+	if reason := walks.overBudget(); reason != "" {
+		walks.stopped[reason]++
+		return nil
+	}
+
+	wlk.Draw(ctx)
+
+	anchor := Point{wlk.X, wlk.Y}
+	for wlk.InBounds(ctx) {
+		if Stopped(runCtx) {
+			return runCtx.Err()
+		}
+
+		if reason := walks.limit(wlk, &anchor); reason != "" {
+			walks.stopped[reason]++
+			return nil
+		}
+
+		wlk.Velocity()
+		wlk.Move()
+		wlk.Draw(ctx)
+		walks.steps++
+
+		if rec != nil {
+			rec.Step()
+		}
+	}
+
+	return nil
+}
+
+// limit is why wlk has to stop before its next step, if it does.
+// anchor is where it was at the start of the stall window.
+func (walks *Walks) limit(wlk *Walker, anchor *Point) string {
+	limits := walks.limits
+
+	if limits.MaxSteps > 0 && wlk.stepCount >= limits.MaxSteps {
+		return stopMaxSteps
+	}
+
+	if limits.StallWindow > 0 && wlk.stepCount > 0 && wlk.stepCount%limits.StallWindow == 0 {
+		if math.Hypot(wlk.X-anchor.x, wlk.Y-anchor.y) < limits.StallDistance {
+			return stopStalled
+		}
+		*anchor = Point{wlk.X, wlk.Y}
+	}
+
+	if limits.StepBudget > 0 && walks.steps >= limits.StepBudget {
+		return stopStepBudget
+	}
+	// the clock is only looked at now and then
+	if walks.steps%1024 == 0 && walks.pastDeadline() {
+		return stopTimeBudget
+	}
+
+	return ""
+}
+
+func (walks *Walks) overBudget() string {
+	if walks.limits.StepBudget > 0 && walks.steps >= walks.limits.StepBudget {
+		return stopStepBudget
+	}
+	if walks.pastDeadline() {
+		return stopTimeBudget
+	}
+	return ""
+}
+
+func (walks *Walks) pastDeadline() bool {
+	return !walks.deadline.IsZero() && time.Now().After(walks.deadline)
+}
+
+// Summary says how many walkers were stopped early and why, it's
+// empty when none were
+func (walks *Walks) Summary() string {
+	var reasons []string
+	total := 0
+	for _, reason := range []string{stopMaxSteps, stopStalled, stopStepBudget, stopTimeBudget} {
+		if n := walks.stopped[reason]; n > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+			total += n
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("stopped %d of %d walkers early, %s", total, walks.walkers, strings.Join(reasons, ", "))
+}
@@ -0,0 +1,133 @@
+package art
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tdewolff/canvas"
+)
+
+// stillNoise never pushes a walker, so it keeps the velocity it
+// starts with
+type stillNoise struct{}
+
+func (stillNoise) Eval2(x, y float64) float64       { return 0 }
+func (stillNoise) Eval3(x, y, z float64) float64    { return 0 }
+func (stillNoise) Eval4(x, y, z, w float64) float64 { return 0 }
+
+// testWalker starts in the middle of a 100x100 canvas moving right by
+// speed every step
+func testWalker(speed float64) *Walker {
+	return &Walker{X: 50, Y: 50, Vx: speed, Noise: stillNoise{}, Color: canvas.Black, StrokeWidth: 1}
+}
+
+func testCanvas() *canvas.Context {
+	return canvas.NewContext(canvas.New(100, 100))
+}
+
+func TestWalkLimits(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		limits      WalkerLimits
+		speed       float64
+		wantSteps   int
+		wantSummary string
+	}{
+		{name: "no limits", speed: 1, wantSteps: 50},
+		{name: "under the step limit", limits: WalkerLimits{MaxSteps: 100}, speed: 1, wantSteps: 50},
+		{
+			name:        "at the step limit",
+			limits:      WalkerLimits{MaxSteps: 10},
+			speed:       0,
+			wantSteps:   10,
+			wantSummary: "stopped 1 of 1 walkers early, 1 at the step limit",
+		},
+		{
+			name:        "standing still",
+			limits:      WalkerLimits{MaxSteps: 1000, StallWindow: 20, StallDistance: 1},
+			speed:       0,
+			wantSteps:   20,
+			wantSummary: "stopped 1 of 1 walkers early, 1 stalled",
+		},
+		{
+			name:        "crawling",
+			limits:      WalkerLimits{StallWindow: 20, StallDistance: 1},
+			speed:       0.01,
+			wantSteps:   20,
+			wantSummary: "stopped 1 of 1 walkers early, 1 stalled",
+		},
+		{name: "slow but moving", limits: WalkerLimits{StallWindow: 20, StallDistance: 1}, speed: 0.125, wantSteps: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			walks := tt.limits.Start()
+			wlk := testWalker(tt.speed)
+
+			if err := walks.Walk(context.Background(), testCanvas(), wlk, nil); err != nil {
+				t.Fatal(err)
+			}
+			if wlk.stepCount != tt.wantSteps {
+				t.Errorf("took %d steps, want %d", wlk.stepCount, tt.wantSteps)
+			}
+			if got := walks.Summary(); got != tt.wantSummary {
+				t.Errorf("got summary %q, want %q", got, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestWalkStepBudget(t *testing.T) {
+
+	walks := WalkerLimits{StepBudget: 80}.Start()
+	ctx := testCanvas()
+
+	// 50 steps each, the budget runs out in the second walker and the
+	// third one never starts
+	var steps []int
+	for i := 0; i < 3; i++ {
+		wlk := testWalker(1)
+		if err := walks.Walk(context.Background(), ctx, wlk, nil); err != nil {
+			t.Fatal(err)
+		}
+		steps = append(steps, wlk.stepCount)
+	}
+
+	if steps[0] != 50 || steps[1] != 30 || steps[2] != 0 {
+		t.Errorf("walkers took %v steps, want [50 30 0]", steps)
+	}
+	if got, want := walks.Summary(), "stopped 2 of 3 walkers early, 2 out of step budget"; got != want {
+		t.Errorf("got summary %q, want %q", got, want)
+	}
+}
+
+func TestWalkTimeBudget(t *testing.T) {
+
+	walks := WalkerLimits{TimeBudget: time.Nanosecond}.Start()
+	time.Sleep(time.Millisecond)
+
+	wlk := testWalker(1)
+	if err := walks.Walk(context.Background(), testCanvas(), wlk, nil); err != nil {
+		t.Fatal(err)
+	}
+	if wlk.stepCount != 0 {
+		t.Errorf("took %d steps after the time budget ran out", wlk.stepCount)
+	}
+	if got, want := walks.Summary(), "stopped 1 of 1 walkers early, 1 out of time budget"; got != want {
+		t.Errorf("got summary %q, want %q", got, want)
+	}
+}
+
+func TestWalkCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := WalkerLimits{}.Start().Walk(ctx, testCanvas(), testWalker(1), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
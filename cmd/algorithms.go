@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mangofeet/netrunner-alt-gen/art"
+	"github.com/spf13/cobra"
+)
+
+// algorithm is an art algorithm that can be chosen by name for the
+// batch commands, built from the current flag values
+type algorithm struct {
+	drawer   func() art.Drawer
+	designer string
+}
+
+var algorithms = map[string]algorithm{
+	"netwalker":  {netwalkerDrawer, "mangofeet"},
+	"netringer":  {netringerDrawer, "mangofeet"},
+	"phungus":    {phungusDrawer, "mangofeet"},
+	"anglemorph": {anglemorphDrawer, "mangofeet"},
+	"reflection": {reflectionDrawer, "mangofeet"},
+	"empty":      {func() art.Drawer { return emptyDrawer{} }, ""},
+}
+
+func algorithmNames() []string {
+	var names []string
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getAlgorithm(name string) (algorithm, error) {
+	alg, ok := algorithms[name]
+	if !ok {
+		return alg, fmt.Errorf(`unknown algorithm "%s", expected one of %s`, name, strings.Join(algorithmNames(), ", "))
+	}
+	return alg, nil
+}
+
+// algorithmFlags registers the flags of every algorithm, for commands
+// where the algorithm is picked with --algorithm
+func algorithmFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&algorithmName, "algorithm", "a", "netwalker", fmt.Sprintf("Algorithm used for the art, one of %s", strings.Join(algorithmNames(), ", ")))
+	commonNetspaceFlags(cmd)
+	cmd.Flags().StringVarP(&altColor1, "ring-color-1", "", "", `Alternate ring color for the netringer and phungus algorithms`)
+	cmd.Flags().StringVarP(&altColor2, "ring-color-2", "", "", `Alternate ring color for the netringer and phungus algorithms`)
+	cmd.Flags().StringVarP(&altColor3, "ring-color-3", "", "", `Alternate ring color for the netringer and phungus algorithms`)
+	cmd.Flags().StringVarP(&altColor4, "ring-color-4", "", "", `Alternate ring color for the netringer and phungus algorithms`)
+}
@@ -5,13 +5,15 @@ import (
 	"os"
 	"strings"
 
+	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/art/anglemorph"
+	"github.com/mangofeet/nrdb-go"
 	"github.com/spf13/cobra"
 )
 
 var anglemorphCmd = &cobra.Command{
 	Use:   "anglemorph [card name or printing ID]",
-	Args:  cobra.MinimumNArgs(1),
+	Args:  cardArgs(1),
 	Short: `Generate a card using the "anglemorph" algorithm`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -26,18 +28,16 @@ var anglemorphCmd = &cobra.Command{
 }
 
 func generateCardAnglemorph(cardName string) error {
-	printing, err := getCardData(cardName)
-	if err != nil {
-		return err
-	}
-	log.Printf("generating %s", printing.Attributes.StrippedTitle)
+	return forEachCard(cardName, "anglemorph", func(printing *nrdb.Printing) error {
+		return generateCard(anglemorphDrawer(), printing, "anglemorph", "mangofeet")
+	})
+}
 
-	ns := anglemorph.AngleMorph{
+func anglemorphDrawer() art.Drawer {
+	return anglemorph.AngleMorph{
 		ColumnCount: 60,
 		RowCount:    90,
 		Color:       parseColor(baseColor),
 		ColorBG:     parseColor(colorBG),
 	}
-
-	return generateCard(ns, printing, "anglemorph", "mangofeet")
 }
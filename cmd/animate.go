@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mangofeet/netrunner-alt-gen/art"
+	"github.com/mangofeet/netrunner-alt-gen/internal/apng"
+	"github.com/mangofeet/netrunner-alt-gen/layout"
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+)
+
+var animateFormats = []string{"none", "gif", "apng", "frames"}
+
+// animations are written next to the card with these suffixes, as a
+// file or a directory of frames
+const (
+	animationSuffix = "-animation"
+	framesSuffix    = "-frames"
+)
+
+// animateHold is how long the finished card stays up before the
+// animation starts over
+const animateHold = 2 * time.Second
+
+// animator passes everything drawn on to the card canvas and also
+// draws it on a small raster, which is captured as a frame every
+// --animate-every walker steps and for every ring
+type animator struct {
+	canvas.Renderer
+	raster     *layout.Rasterizer
+	art        *image.RGBA
+	resolution canvas.Resolution
+	overlay    image.Image
+	steps      int
+	frames     [][]byte
+
+	// the last ring image and a copy at the animation size
+	rings      image.Image
+	ringsSmall *image.RGBA
+}
+
+// newAnimator hooks an animator up to a drawer, the art has to be drawn
+// on a context of the animator for it to see anything
+func newAnimator(drawer art.Drawer, cnv *canvas.Canvas, r cardRender) (art.Drawer, *animator, error) {
+	recordable, ok := drawer.(art.Recordable)
+	if !ok {
+		return nil, nil, fmt.Errorf(`the %s algorithm can't be animated, --animate works with netwalker, netringer, phungus and tracker`, r.algorithm)
+	}
+
+	resolution := canvas.DPMM(float64(animateWidth) / canvasWidth)
+	anim := &animator{
+		Renderer:   cnv,
+		art:        image.NewRGBA(image.Rect(0, 0, animateWidth, int(canvasHeight*resolution.DPMM()+0.5))),
+		resolution: resolution,
+	}
+	anim.raster = layout.NewRasterizer(anim.art, anim.art.Bounds().Dy(), resolution)
+
+	frameCnv, err := renderFrame(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if frameCnv != nil {
+		anim.overlay = rasterizer.Draw(frameCnv, resolution, canvas.DefaultColorSpace)
+	}
+
+	return recordable.Record(anim), anim, nil
+}
+
+func (anim *animator) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	anim.Renderer.RenderPath(path, style, m)
+	anim.raster.RenderPath(path, style, m)
+}
+
+func (anim *animator) RenderText(text *canvas.Text, m canvas.Matrix) {
+	anim.Renderer.RenderText(text, m)
+	anim.raster.RenderText(text, m)
+}
+
+func (anim *animator) RenderImage(img image.Image, m canvas.Matrix) {
+	anim.Renderer.RenderImage(img, m)
+
+	// finished rings were already scaled down for their frames
+	if img == anim.rings && m == layout.ImageMatrix() {
+		draw.Draw(anim.art, anim.art.Bounds(), anim.ringsSmall, image.Point{}, draw.Over)
+		return
+	}
+	anim.raster.RenderImage(img, m)
+}
+
+func (anim *animator) Step() {
+	anim.steps++
+	if anim.steps%animateEvery == 0 {
+		anim.capture(nil)
+	}
+}
+
+func (anim *animator) Ring(img image.Image, x, y, radius float64) {
+	if img != anim.rings {
+		small := image.NewRGBA(anim.art.Bounds())
+		layout.NewRasterizer(small, small.Bounds().Dy(), anim.resolution).RenderImage(img, layout.ImageMatrix())
+		anim.rings, anim.ringsSmall = img, small
+	}
+
+	anim.capture(func(dst *image.RGBA) {
+		if math.IsInf(radius, 1) {
+			draw.Draw(dst, dst.Bounds(), anim.ringsSmall, image.Point{}, draw.Over)
+			return
+		}
+
+		// copy the disc within radius a row at a time
+		dpmm := anim.resolution.DPMM()
+		cx, cy, r := x*dpmm, float64(dst.Bounds().Dy())-y*dpmm, radius*dpmm
+		for row := max(0, int(cy-r)); row < min(dst.Bounds().Dy(), int(cy+r)+1); row++ {
+			half := math.Sqrt(math.Max(0, r*r-math.Pow(float64(row)+0.5-cy, 2)))
+			span := image.Rect(int(cx-half), row, int(cx+half), row+1)
+			draw.Draw(dst, span, anim.ringsSmall, span.Min, draw.Over)
+		}
+	})
+}
+
+// capture keeps the art drawn so far as a frame, with anything extra
+// from pending drawn over it and the card frame on top
+func (anim *animator) capture(pending func(dst *image.RGBA)) {
+	img := image.NewRGBA(anim.art.Bounds())
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+	draw.Draw(img, img.Bounds(), anim.art, image.Point{}, draw.Over)
+	if pending != nil {
+		pending(img)
+	}
+	if anim.overlay != nil {
+		draw.Draw(img, img.Bounds(), anim.overlay, image.Point{}, draw.Over)
+	}
+
+	// frames are kept compressed, there can be hundreds of them
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Println("warning: skipping animation frame:", err)
+		return
+	}
+	anim.frames = append(anim.frames, buf.Bytes())
+}
+
+// write adds the finished card as the last frame and writes the
+// animation next to the card
+func (anim *animator) write(r cardRender) error {
+	anim.capture(nil)
+
+	base, err := outputPath(outputDir, r, "")
+	if err != nil {
+		return err
+	}
+
+	delay := time.Duration(float64(time.Second) / animateFPS)
+
+	switch animateFormat {
+	case "gif":
+		filename := base + animationSuffix + ".gif"
+		r.logger().Printf("writing %d frame animation to %s", len(anim.frames), filename)
+		return anim.writeGIF(filename, delay)
+
+	case "apng":
+		filename := base + animationSuffix + ".png"
+		r.logger().Printf("writing %d frame animation to %s", len(anim.frames), filename)
+
+		frames := make([]apng.Frame, len(anim.frames))
+		for i, data := range anim.frames {
+			frames[i] = apng.Frame{PNG: data, Delay: delay}
+		}
+		frames[len(frames)-1].Delay = animateHold
+
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := apng.Write(f, frames); err != nil {
+			return err
+		}
+		return f.Close()
+
+	case "frames":
+		dir := base + framesSuffix
+		r.logger().Printf("writing %d animation frames to %s", len(anim.frames), dir)
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		for i, data := range anim.frames {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%04d.png", i+1)), data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (anim *animator) writeGIF(filename string, delay time.Duration) error {
+	// the finished card has every color the animation gets to
+	last, err := png.Decode(bytes.NewReader(anim.frames[len(anim.frames)-1]))
+	if err != nil {
+		return err
+	}
+	quantize := newQuantizer(last)
+
+	out := gif.GIF{LoopCount: 0}
+	for i, data := range anim.frames {
+		img := last
+		if i < len(anim.frames)-1 {
+			if img, err = png.Decode(bytes.NewReader(data)); err != nil {
+				return err
+			}
+		}
+
+		// gif delays are in hundredths of a second, browsers slow
+		// down anything under 2
+		out.Image = append(out.Image, quantize(img))
+		out.Delay = append(out.Delay, max(2, int(delay.Milliseconds()/10)))
+	}
+	out.Delay[len(out.Delay)-1] = int(animateHold.Milliseconds() / 10)
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := gif.EncodeAll(f, &out); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// newQuantizer picks the 256 most common colors of img, at 5 bits per
+// channel, and returns a function mapping images onto them. Frames
+// aren't dithered so the parts that don't change stay the same.
+func newQuantizer(img image.Image) func(image.Image) *image.Paletted {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := map[int]*bucket{}
+
+	key := func(c color.RGBA) int {
+		return int(c.R>>3)<<10 | int(c.G>>3)<<5 | int(c.B>>3)
+	}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			b, ok := buckets[key(c)]
+			if !ok {
+				b = &bucket{}
+				buckets[key(c)] = b
+			}
+			b.count++
+			b.r += int(c.R)
+			b.g += int(c.G)
+			b.b += int(c.B)
+		}
+	}
+
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, b := range buckets {
+		sorted = append(sorted, b)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].count > sorted[j].count })
+
+	var palette color.Palette
+	for _, b := range sorted[:min(256, len(sorted))] {
+		palette = append(palette, color.RGBA{
+			R: uint8(b.r / b.count),
+			G: uint8(b.g / b.count),
+			B: uint8(b.b / b.count),
+			A: 0xff,
+		})
+	}
+
+	// looking up the closest palette color is slow, remember it for
+	// every bucket
+	lookup := map[int]uint8{}
+
+	return func(img image.Image) *image.Paletted {
+		bounds := img.Bounds()
+		out := image.NewPaletted(bounds, palette)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				index, ok := lookup[key(c)]
+				if !ok {
+					index = uint8(palette.Index(c))
+					lookup[key(c)] = index
+				}
+				out.SetColorIndex(x, y, index)
+			}
+		}
+		return out
+	}
+}
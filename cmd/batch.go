@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mangofeet/netrunner-alt-gen/internal/query"
+	"github.com/mangofeet/nrdb-go"
+)
+
+// renderBatch renders every printing with the algorithm chosen by
+// --algorithm
+func renderBatch(printings []*nrdb.Printing) error {
+
+	alg, err := getAlgorithm(algorithmName)
+	if err != nil {
+		return err
+	}
+
+	return renderEach(printings, algorithmName, func(printing *nrdb.Printing) error {
+		return generateCard(alg.drawer(), printing, algorithmName, alg.designer)
+	})
+}
+
+// renderEach calls generate for every printing, --jobs of them at a
+// time. A failing card doesn't stop the rest, the failures are listed
+// in the summary at the end.
+func renderEach(printings []*nrdb.Printing, algorithm string, generate func(printing *nrdb.Printing) error) error {
+
+	start := time.Now()
+
+	jobs, err := batchJobs(len(printings))
+	if err != nil {
+		return err
+	}
+	if jobs > 1 {
+		log.Printf("rendering %d cards at a time", jobs)
+	}
+
+	errs := make([]error, len(printings))
+	parallel(len(printings), jobs, func(i int) {
+		printing := printings[i]
+		if interrupted.Err() != nil {
+			// the cards after Ctrl-C aren't started
+			errs[i] = errInterrupted
+			return
+		}
+		log.Printf("[%d/%d] generating %s", i+1, len(printings), printing.Attributes.StrippedTitle)
+
+		if err := generate(printing); err != nil {
+			log.Printf("error: %s: %s", printing.Attributes.StrippedTitle, err)
+			errs[i] = err
+		}
+	})
+
+	type failure struct {
+		printing *nrdb.Printing
+		err      error
+	}
+	var failures []failure
+	unfinished := 0
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, errInterrupted):
+			unfinished++
+		case err != nil:
+			failures = append(failures, failure{printings[i], err})
+		}
+	}
+
+	log.Printf("rendered %d of %d cards with %s in %s", len(printings)-len(failures)-unfinished, len(printings), algorithm, time.Since(start).Round(time.Second))
+	for _, f := range failures {
+		log.Printf("  failed %s - %s: %s", f.printing.ID, f.printing.Attributes.StrippedTitle, f.err)
+	}
+
+	if unfinished > 0 {
+		return fmt.Errorf("interrupted, %d cards weren't rendered", unfinished)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d cards failed", len(failures))
+	}
+
+	return nil
+}
+
+// filterPrintings keeps the printings matching the --types,
+// --factions and --query filters. "identity" matches both corp and
+// runner identities.
+func filterPrintings(printings []*nrdb.Printing) ([]*nrdb.Printing, error) {
+
+	if cardQuery != "" {
+		q, err := query.Parse(cardQuery)
+		if err != nil {
+			return nil, fmt.Errorf("parsing query: %w", err)
+		}
+		printings = q.Filter(printings)
+	}
+
+	var filtered []*nrdb.Printing
+	for _, printing := range printings {
+		cardType := printing.Attributes.CardTypeID
+		if len(filterTypes) > 0 && !slices.Contains(filterTypes, cardType) &&
+			!(strings.HasSuffix(cardType, "_identity") && slices.Contains(filterTypes, "identity")) {
+			continue
+		}
+		if len(filterFactions) > 0 && !slices.Contains(filterFactions, printing.Attributes.FactionID) {
+			continue
+		}
+		filtered = append(filtered, printing)
+	}
+
+	return filtered, nil
+}
+
+// queryCards returns the printings matching --query, or the card
+// named on the command line when there is no query. A query matches
+// printings, so reprints are reduced to the latest matching printing
+// of each card.
+func queryCards(cardName string) ([]*nrdb.Printing, error) {
+
+	if cardQuery == "" {
+		printing, err := getCardData(cardName)
+		if err != nil {
+			return nil, err
+		}
+		return []*nrdb.Printing{printing}, nil
+	}
+
+	q, err := query.Parse(cardQuery)
+	if err != nil {
+		return nil, fmt.Errorf("parsing query: %w", err)
+	}
+
+	src, err := getCardSource()
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := src.All()
+	if err != nil {
+		return nil, fmt.Errorf("getting card data: %w", err)
+	}
+
+	if cardName != "" {
+		var named []*nrdb.Printing
+		for _, printing := range all {
+			if strings.Contains(strings.ToLower(printing.Attributes.Title), strings.ToLower(cardName)) {
+				named = append(named, printing)
+			}
+		}
+		all = named
+	}
+
+	// keep one printing of each card, the latest one if it matches
+	var printings []*nrdb.Printing
+	byCard := map[string]int{}
+	for _, printing := range q.Filter(all) {
+		cardID := printing.Attributes.CardID
+		if cardID == "" {
+			cardID = printing.ID
+		}
+		if i, ok := byCard[cardID]; ok {
+			if printing.Attributes.IsLatestPrinting || !printings[i].Attributes.IsLatestPrinting {
+				printings[i] = printing
+			}
+			continue
+		}
+		byCard[cardID] = len(printings)
+		printings = append(printings, printing)
+	}
+
+	if len(printings) == 0 {
+		return nil, fmt.Errorf("no cards match: %s", cardQuery)
+	}
+	log.Printf("%d cards match: %s", len(printings), cardQuery)
+
+	return printings, nil
+}
+
+// forEachCard calls generate for the card named on the command line,
+// or for every card matching --query
+func forEachCard(cardName, algorithm string, generate func(printing *nrdb.Printing) error) error {
+
+	printings, err := queryCards(cardName)
+	if err != nil {
+		return err
+	}
+
+	if len(printings) == 1 {
+		log.Printf("generating %s", printings[0].Attributes.StrippedTitle)
+		return generate(printings[0])
+	}
+
+	return renderEach(printings, algorithm, generate)
+}
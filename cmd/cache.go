@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mangofeet/netrunner-alt-gen/internal/httpcache"
+	"github.com/mangofeet/netrunner-alt-gen/internal/source"
+	"github.com/mangofeet/nrdb-go"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the NRDB response cache",
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "List cached responses",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cacheInfo(); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired responses from the cache",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cachePrune(); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm [card set or cycle ID]...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Fetch every printing in the given sets or cycles into the cache",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cacheWarm(args); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func getHTTPCache() (*httpcache.Cache, error) {
+	dir := cacheDir
+	if dir == "" {
+		defaultDir, err := httpcache.DefaultDir()
+		if err != nil {
+			return nil, fmt.Errorf("finding cache directory: %w", err)
+		}
+		dir = defaultDir
+	}
+
+	return &httpcache.Cache{
+		Dir:     dir,
+		TTL:     cacheTTL,
+		Offline: offline,
+	}, nil
+}
+
+// getNRDBClient returns an NRDB client going through the response
+// cache unless it's disabled
+func getNRDBClient() (nrdb.Client, error) {
+	client := nrdb.NewClient()
+
+	if noCache {
+		if offline {
+			return nil, fmt.Errorf("--offline needs the cache, remove --no-cache")
+		}
+		return client, nil
+	}
+
+	cache, err := getHTTPCache()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.WithHTTPClient(http.Client{
+		Timeout:   time.Second * 30,
+		Transport: cache,
+	}), nil
+}
+
+func cacheInfo() error {
+	cache, err := getHTTPCache()
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	var expired int
+	for _, entry := range entries {
+		status := ""
+		if cache.Expired(entry) {
+			status = " (expired)"
+			expired++
+		}
+		log.Printf("%s  %8d  %s%s", entry.StoredAt.Format(time.DateTime), entry.Size, entry.URL, status)
+		size += entry.Size
+	}
+
+	log.Printf("%d responses, %d expired, %d bytes in %s", len(entries), expired, size, cache.Dir)
+
+	return nil
+}
+
+func cachePrune() error {
+	cache, err := getHTTPCache()
+	if err != nil {
+		return err
+	}
+
+	removed, err := cache.Prune(cachePruneAll)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("removed %d responses from %s", removed, cache.Dir)
+
+	return nil
+}
+
+func cacheWarm(sets []string) error {
+	client, err := getNRDBClient()
+	if err != nil {
+		return err
+	}
+
+	for _, set := range sets {
+		printings, err := source.NewNRDB(client).InSet(set)
+		if err != nil {
+			return fmt.Errorf("fetching %s: %w", set, err)
+		}
+		if len(printings) == 0 {
+			return fmt.Errorf("no printings found for %s", set)
+		}
+
+		// single printing lookups are separate requests, fetch those
+		// too so ID lookups hit the cache. Title searches are requests
+		// of their own that can't be guessed, offline they are
+		// answered from these printings instead, see cachedPrintings.
+		for _, printing := range printings {
+			if _, err := client.Printing(printing.ID); err != nil {
+				return fmt.Errorf("fetching printing %s: %w", printing.ID, err)
+			}
+		}
+
+		log.Printf("cached %d printings for %s", len(printings), set)
+	}
+
+	return nil
+}
+
+// cachedPrintings collects every printing held in the cache, from
+// both printing listings and single printing responses, so lookups
+// that were never requested themselves can still be answered offline
+func cachedPrintings() (*source.Memory, error) {
+	cache, err := getHTTPCache()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var printings []*nrdb.Printing
+	add := func(printing *nrdb.Printing) {
+		if printing == nil || printing.Attributes == nil || seen[printing.ID] {
+			return
+		}
+		seen[printing.ID] = true
+		printings = append(printings, printing)
+	}
+
+	for _, entry := range entries {
+		path, _, _ := strings.Cut(entry.URL, "?")
+		switch {
+		case strings.HasSuffix(path, "/printings"):
+			var res nrdb.Response[[]*nrdb.Printing]
+			if err := json.Unmarshal(entry.Body, &res); err != nil {
+				return nil, fmt.Errorf("reading cached %s: %w", entry.URL, err)
+			}
+			for _, printing := range res.Data {
+				add(printing)
+			}
+		case strings.Contains(path, "/printings/"):
+			var res nrdb.Response[*nrdb.Printing]
+			if err := json.Unmarshal(entry.Body, &res); err != nil {
+				return nil, fmt.Errorf("reading cached %s: %w", entry.URL, err)
+			}
+			add(res.Data)
+		}
+	}
+
+	return source.Fixture(printings...), nil
+}
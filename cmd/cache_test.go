@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mangofeet/netrunner-alt-gen/internal/httpcache"
+	"github.com/mangofeet/nrdb-go"
+)
+
+// setListing answers printing listings with a warmed set and nothing
+// else, like the requests cache warm makes
+type setListing []*nrdb.Printing
+
+func (set setListing) RoundTrip(req *http.Request) (*http.Response, error) {
+	res := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       io.NopCloser(strings.NewReader("not found")),
+		Request:    req,
+	}
+	if strings.HasSuffix(req.URL.Path, "/printings") {
+		body, err := json.Marshal(nrdb.Response[[]*nrdb.Printing]{Data: set})
+		if err != nil {
+			return nil, err
+		}
+		res.StatusCode = http.StatusOK
+		res.Body = io.NopCloser(strings.NewReader(string(body)))
+	}
+	return res, nil
+}
+
+func TestOfflineTitleLookup(t *testing.T) {
+
+	dir := t.TempDir()
+
+	hedgeFund := seedCard()
+	hedgeFund.Attributes.CardID = "hedge_fund"
+	hedgeFund.Attributes.CardSetID = "core"
+	sureGamble := seedCard()
+	sureGamble.ID = "01051"
+	sureGamble.Attributes.Title = "Sure Gamble"
+	sureGamble.Attributes.CardID = "sure_gamble"
+	sureGamble.Attributes.CardSetID = "core"
+
+	warm := nrdb.NewClient().WithHTTPClient(http.Client{
+		Transport: &httpcache.Cache{Dir: dir, Transport: setListing{hedgeFund, sureGamble}},
+	})
+	if _, err := warm.AllPrintings(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, origOffline, origSpec, origSource := cacheDir, offline, cardSourceSpec, cardSource
+	t.Cleanup(func() {
+		cacheDir, offline, cardSourceSpec, cardSource = origDir, origOffline, origSpec, origSource
+	})
+	cacheDir, offline, cardSourceSpec, cardSource = dir, true, "nrdb", nil
+
+	printing, err := getCardData("sure gam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if printing.ID != "01051" {
+		t.Errorf("got %s, want 01051", printing.ID)
+	}
+
+	_, err = getCardData("account siphon")
+	if err == nil || !strings.Contains(err.Error(), httpcache.ErrNotCached.Error()) {
+		t.Errorf("got %v, want a %q error", err, httpcache.ErrNotCached)
+	}
+
+	_, err = getCardData("01052")
+	if err == nil || !strings.Contains(err.Error(), "no result for printing ID 01052") {
+		t.Errorf("got %v, want a missing printing error", err)
+	}
+}
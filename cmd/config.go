@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// loadConfig applies settings from the config file to any flag that
+// wasn't set on the command line. The file is a JSON object keyed by
+// flag name, e.g. {"card-db": "/home/me/nrdb"}. It lives at
+// $XDG_CONFIG_HOME/netrunner-alt-gen/config.json (or the platform
+// equivalent) and can be moved with the --config flag.
+func loadConfig(cmd *cobra.Command) error {
+
+	path := configFile
+	if path == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(configDir, "netrunner-alt-gen", "config.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// only complain about a missing file if it was asked for
+		if errors.Is(err, fs.ErrNotExist) && configFile == "" {
+			return nil
+		}
+		return err
+	}
+
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	var setErr error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		value, ok := settings[flag.Name]
+		if !ok || flag.Changed || setErr != nil {
+			return
+		}
+
+		str, err := configValue(value)
+		if err == nil {
+			// through the flag set, so the flag counts as changed
+			err = cmd.Flags().Set(flag.Name, str)
+		}
+		if err != nil {
+			setErr = fmt.Errorf(`config %s: invalid value for "%s": %w`, path, flag.Name, err)
+			return
+		}
+		configFlags[flag.Name] = true
+	})
+
+	return setErr
+}
+
+// configFlags are the flags set from the config file rather than the
+// command line
+var configFlags = map[string]bool{}
+
+// configValue turns a JSON value into what the flag would be given on
+// the command line, lists are joined with commas like slice flags
+// expect
+func configValue(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		// no exponent, 1000000 has to stay 1000000 for int flags
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			if _, ok := item.([]any); ok {
+				return "", fmt.Errorf("lists can't be nested")
+			}
+			str, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = str
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("expected a string, number, boolean or list, got %s", jsonType(value))
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
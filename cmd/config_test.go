@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// loadTestConfig runs loadConfig for a command with a few flags of
+// each kind, args are parsed first as if typed on the command line
+func loadTestConfig(t *testing.T, config string, args ...string) (*cobra.Command, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origFile, origFlags := configFile, configFlags
+	t.Cleanup(func() { configFile, configFlags = origFile, origFlags })
+	configFile, configFlags = path, map[string]bool{}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("color", "", "")
+	cmd.Flags().Int("walkers", 0, "")
+	cmd.Flags().Float64("noise", 0, "")
+	cmd.Flags().Bool("skip-flavor", false, "")
+	cmd.Flags().StringSlice("seed-fields", nil, "")
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return cmd, loadConfig(cmd)
+}
+
+func TestLoadConfig(t *testing.T) {
+
+	cmd, err := loadTestConfig(t, `{
+		"color": "#ff0000",
+		"walkers": 1000000,
+		"noise": 0.0005,
+		"skip-flavor": true,
+		"seed-fields": ["title", "faction"],
+		"not-a-flag": 1
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := cmd.Flags()
+	if got, _ := flags.GetString("color"); got != "#ff0000" {
+		t.Errorf("color is %s", got)
+	}
+	if got, _ := flags.GetInt("walkers"); got != 1000000 {
+		t.Errorf("walkers is %d", got)
+	}
+	if got, _ := flags.GetFloat64("noise"); got != 0.0005 {
+		t.Errorf("noise is %f", got)
+	}
+	if got, _ := flags.GetBool("skip-flavor"); !got {
+		t.Error("skip-flavor isn't set")
+	}
+	if got, _ := flags.GetStringSlice("seed-fields"); !slices.Equal(got, []string{"title", "faction"}) {
+		t.Errorf("seed-fields is %q", got)
+	}
+
+	for _, name := range []string{"color", "walkers", "noise", "skip-flavor", "seed-fields"} {
+		if !flags.Lookup(name).Changed || !configFlags[name] {
+			t.Errorf("%s isn't marked as set from the config", name)
+		}
+	}
+}
+
+func TestLoadConfigCommandLineWins(t *testing.T) {
+
+	cmd, err := loadTestConfig(t, `{"walkers": 50, "seed-fields": ["title"]}`, "--walkers", "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := cmd.Flags().GetInt("walkers"); got != 7 {
+		t.Errorf("walkers is %d, want the command line's 7", got)
+	}
+	if configFlags["walkers"] {
+		t.Error("walkers is recorded as coming from the config")
+	}
+	if got, _ := cmd.Flags().GetStringSlice("seed-fields"); !slices.Equal(got, []string{"title"}) {
+		t.Errorf("seed-fields is %q", got)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+
+	for config, want := range map[string]string{
+		`{"walkers": 2.5}`:             `invalid value for "walkers"`,
+		`{"walkers": "many"}`:          `invalid value for "walkers"`,
+		`{"color": {"bg": "#000"}}`:    "got an object",
+		`{"seed-fields": [["title"]]}`: "lists can't be nested",
+		`{"skip-flavor": null}`:        "got null",
+		`["walkers"]`:                  "parsing config",
+	} {
+		_, err := loadTestConfig(t, config)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want an error containing %q", config, err, want)
+		}
+	}
+}
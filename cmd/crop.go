@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/mangofeet/netrunner-alt-gen/layout"
+	"github.com/tdewolff/canvas"
+)
+
+var cropModes = []string{"none", "bleed", "trim", "safe"}
+
+// isCropped is true when the output differs from the full bleed
+// canvas
+func isCropped() bool {
+	return (cropMode != "none" && cropMode != "bleed") || previewRoundedCorners
+}
+
+// centeredRect is a w by h box in the middle of the canvas
+func centeredRect(w, h float64) canvas.Rect {
+	return canvas.Rect{
+		X: (canvasWidth - w) / 2,
+		Y: (canvasHeight - h) / 2,
+		W: w,
+		H: h,
+	}
+}
+
+// cropRect is the part of the canvas kept by --crop
+func cropRect() canvas.Rect {
+	switch cropMode {
+	case "trim":
+		return centeredRect(cardWidth, cardHeight)
+	case "safe":
+		return centeredRect(safeWidth, safeHeight)
+	}
+	return centeredRect(canvasWidth, canvasHeight)
+}
+
+// cropCanvas returns a new canvas holding the --crop area of cnv, with
+// everything outside the rounded cut line cleared if
+// --preview-rounded-corners is set
+func cropCanvas(cnv *canvas.Canvas) *canvas.Canvas {
+	rect := cropRect()
+
+	cropped := canvas.New(rect.W, rect.H)
+	cnv.RenderViewTo(cropped, canvas.Identity.Translate(-rect.X, -rect.Y))
+
+	if !previewRoundedCorners {
+		return cropped
+	}
+
+	cut := centeredRect(cardWidth, cardHeight)
+
+	maskCnv := canvas.New(rect.W, rect.H)
+	maskCtx := canvas.NewContext(maskCnv)
+	maskCtx.SetFillColor(canvas.Black)
+	maskCtx.DrawPath(cut.X-rect.X, cut.Y-rect.Y, canvas.RoundedRectangle(cut.W, cut.H, layout.CornerRadius))
+
+	rounded := layout.Tile(layout.RasterSize(cropped), func(dst *image.RGBA) {
+		img := layout.RasterizeRows(cropped, dst.Rect)
+		mask := layout.RasterizeRows(maskCnv, dst.Rect)
+		draw.DrawMask(dst, dst.Rect, img, dst.Rect.Min, mask, dst.Rect.Min, draw.Src)
+	})
+
+	result := canvas.New(rect.W, rect.H)
+	canvas.NewContext(result).RenderImage(rounded, layout.ImageMatrix())
+
+	return result
+}
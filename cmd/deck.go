@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mangofeet/netrunner-alt-gen/internal/deck"
+	"github.com/mangofeet/netrunner-alt-gen/internal/source"
+	"github.com/mangofeet/nrdb-go"
+	"github.com/spf13/cobra"
+)
+
+var deckCmd = &cobra.Command{
+	Use:   "deck [deck list file]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Generate every card in a deck list",
+	Long: `Generate every card in a deck list.
+
+The list can be an NRDB plain text export, a jinteki.net paste or any
+list of "3x Card Name" lines. The identity is included when the list
+names it. Each card is rendered once, with --pnp the cards are laid
+out on a print & play PDF instead, with as many copies as the list
+asks for.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := generateDeck(args[0]); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+type deckCard struct {
+	printing *nrdb.Printing
+	quantity int
+}
+
+func generateDeck(path string) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries, err := deck.Parse(file)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	src, err := getCardSource()
+	if err != nil {
+		return err
+	}
+
+	var cards []deckCard
+	unresolved := 0
+	for _, entry := range entries {
+
+		printing, suggestions, err := resolveDeckEntry(src, entry)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, entry.Line, err)
+		}
+
+		if printing == nil {
+			// uncounted lines are headers unless they name an identity
+			if !entry.Counted {
+				continue
+			}
+
+			unresolved++
+			if len(suggestions) > 0 {
+				log.Printf(`%s:%d: no card named "%s", did you mean: %s`, path, entry.Line, entry.Name, strings.Join(suggestions, ", "))
+			} else {
+				log.Printf(`%s:%d: no card named "%s"`, path, entry.Line, entry.Name)
+			}
+			continue
+		}
+
+		card := deckCard{printing, entry.Quantity}
+		if isIdentity(printing) {
+			cards = append([]deckCard{card}, cards...)
+		} else {
+			cards = append(cards, card)
+		}
+	}
+
+	copies := 0
+	for _, card := range cards {
+		copies += card.quantity
+	}
+	log.Printf("found %d cards (%d copies) in %s, %d lines unresolved", len(cards), copies, path, unresolved)
+
+	if len(cards) == 0 {
+		return fmt.Errorf("no cards found in %s", path)
+	}
+
+	if pnpDeck {
+		var printings []*nrdb.Printing
+		for _, card := range cards {
+			for i := 0; i < card.quantity; i++ {
+				printings = append(printings, card.printing)
+			}
+		}
+		err = writePnPFile(printings, nil)
+	} else {
+		printings := make([]*nrdb.Printing, len(cards))
+		for i, card := range cards {
+			printings[i] = card.printing
+		}
+		err = renderBatch(printings)
+	}
+	if err != nil {
+		return err
+	}
+
+	if unresolved > 0 {
+		return fmt.Errorf("%d lines of %s could not be resolved", unresolved, path)
+	}
+
+	return nil
+}
+
+// resolveDeckEntry finds the printing for a deck list line. Deck
+// lists use full card titles, so only exact matches are used, the
+// closest other titles are returned as suggestions. Lines without a
+// quantity only resolve to identities.
+func resolveDeckEntry(src source.CardSource, entry deck.Entry) (*nrdb.Printing, []string, error) {
+
+	matches, err := findCards(src, entry.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(matches) == 0 || matches[0].Kind != source.MatchExact {
+		var suggestions []string
+		for i := 0; i < len(matches) && i < 3; i++ {
+			suggestions = append(suggestions, matches[i].Card.StrippedTitle())
+		}
+		return nil, suggestions, nil
+	}
+
+	card := matches[0].Card
+	if !entry.Counted && !strings.HasSuffix(card.Attributes.CardTypeID, "_identity") {
+		return nil, nil, nil
+	}
+
+	printing, err := getPrinting(src, card)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return printing, nil, nil
+}
+
+func isIdentity(printing *nrdb.Printing) bool {
+	return strings.HasSuffix(printing.Attributes.CardTypeID, "_identity")
+}
@@ -12,7 +12,7 @@ import (
 
 var emptyCmd = &cobra.Command{
 	Use:   "empty [card name or printing ID]",
-	Args:  cobra.MinimumNArgs(1),
+	Args:  cardArgs(1),
 	Short: `Generate a frame for the named card`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -26,14 +26,10 @@ var emptyCmd = &cobra.Command{
 }
 
 func generateCardEmpty(cardName string) error {
-	printing, err := getCardData(cardName)
-	if err != nil {
-		return err
-	}
-	log.Printf("generating %s", printing.Attributes.StrippedTitle)
-
-	drawer := emptyDrawer{}
-	return generateCard(drawer, printing, "", "")
+	return forEachCard(cardName, "empty", func(printing *nrdb.Printing) error {
+		drawer := emptyDrawer{}
+		return generateCard(drawer, printing, "", "")
+	})
 }
 
 type emptyDrawer struct {
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mangofeet/netrunner-alt-gen/layout"
+	"github.com/mangofeet/nrdb-go"
+	"github.com/spf13/cobra"
+)
+
+var exploreCmd = &cobra.Command{
+	Use:   "explore [card name or printing ID]",
+	Args:  cardArgs(1),
+	Short: "Render numbered variants of a card's art to pick from",
+	Long: `Render numbered variants of a card's art to pick from.
+
+Every variant is drawn with a different seed, like --variant, at a low
+resolution and laid out on a numbered grid with a JSON file of the seed
+and parameters of each. Both are written to an "explore" directory in
+the output directory. --variant sets the first variant of the grid, so
+--variant 12 --count 12 shows the next page.
+
+Run it again with --render and the numbers of the variants you like to
+render them at full resolution, with the same flags otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		cardName := strings.Join(args, " ")
+
+		if err := exploreCard(cardName); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+
+	},
+}
+
+// exploreManifest is the JSON written next to an explore grid
+type exploreManifest struct {
+	Tool       string            `json:"tool"`
+	Version    string            `json:"version"`
+	Algorithm  string            `json:"algorithm"`
+	PrintingID string            `json:"printing_id"`
+	Title      string            `json:"title"`
+	SeedFields []string          `json:"seed_fields"`
+	Flags      map[string]string `json:"flags"`
+	Colors     map[string]string `json:"colors"`
+	Variants   []exploreVariant  `json:"variants"`
+}
+
+// exploreVariant is a numbered cell of the grid
+type exploreVariant struct {
+	Variant     int    `json:"variant"`
+	Seed        string `json:"seed"`
+	Fingerprint string `json:"fingerprint"`
+	Error       string `json:"error,omitempty"`
+}
+
+func exploreCard(cardName string) error {
+
+	if exploreCount < 1 {
+		return fmt.Errorf("--count must be at least 1, got %d", exploreCount)
+	}
+	if exploreColumns < 1 {
+		return fmt.Errorf("--columns must be at least 1, got %d", exploreColumns)
+	}
+	if thumbWidth < 16 {
+		return fmt.Errorf("--thumb-width must be at least 16, got %d", thumbWidth)
+	}
+	for _, variant := range exploreRender {
+		if variant < 0 {
+			return fmt.Errorf("--render variants must be 0 or more, got %d", variant)
+		}
+	}
+
+	alg, err := getAlgorithm(algorithmName)
+	if err != nil {
+		return err
+	}
+
+	card, err := getCardData(cardName)
+	if err != nil {
+		return err
+	}
+
+	if len(exploreRender) > 0 {
+		return renderVariants(card, alg)
+	}
+
+	return writeExploreGrid(card, alg)
+}
+
+// renderVariants renders the --render variants at full resolution
+func renderVariants(card *nrdb.Printing, alg algorithm) error {
+
+	start := time.Now()
+	var failed []int
+	for i, variant := range exploreRender {
+		if interrupted.Err() != nil {
+			return errInterrupted
+		}
+		log.Printf("[%d/%d] generating %s variant %d", i+1, len(exploreRender), card.Attributes.StrippedTitle, variant)
+
+		r := newCardRender(card, algorithmName, alg.designer)
+		r.variant = variant
+		if err := generateRender(alg.drawer(), r); err != nil {
+			log.Printf("error: variant %d: %s", variant, err)
+			failed = append(failed, variant)
+		}
+	}
+
+	log.Printf("rendered %d of %d variants with %s in %s", len(exploreRender)-len(failed), len(exploreRender), algorithmName, time.Since(start).Round(time.Second))
+	if len(failed) > 0 {
+		return fmt.Errorf("%d variants failed", len(failed))
+	}
+	return nil
+}
+
+// writeExploreGrid renders --count variants from --variant at the
+// resolution of the thumbnails and writes the grid and its manifest
+func writeExploreGrid(card *nrdb.Printing, alg algorithm) error {
+
+	start := time.Now()
+
+	// the art is the same at any DPI, only the raster is smaller
+	fullDPI := layout.DPI
+	layout.DPI = float64(thumbWidth) / layout.CanvasWidthMM * 25.4
+	defer func() { layout.DPI = fullDPI }()
+
+	renders := make([]cardRender, exploreCount)
+	for i := range renders {
+		renders[i] = newCardRender(card, algorithmName, alg.designer)
+		renders[i].variant = seedVariant + i
+	}
+
+	jobs, err := batchJobs(len(renders))
+	if err != nil {
+		return err
+	}
+
+	cells := make([]galleryCard, len(renders))
+	errs := make([]error, len(renders))
+	parallel(len(renders), jobs, func(i int) {
+		r := renders[i]
+		if interrupted.Err() != nil {
+			errs[i] = errInterrupted
+			return
+		}
+		log.Printf("[%d/%d] previewing variant %d", i+1, len(renders), r.variant)
+
+		cnv, _, err := renderCardCanvas(alg.drawer(), r, false)
+		if err != nil {
+			log.Printf("error: variant %d: %s", r.variant, err)
+			errs[i] = err
+			return
+		}
+
+		rec := newRenderRecord(r)
+		cells[i] = galleryCard{
+			Name:      fmt.Sprintf("variant %d", r.variant),
+			Thumbnail: thumbnail(layout.Rasterize(cnv), thumbWidth),
+			Record:    &rec,
+			Label:     fmt.Sprint(r.variant),
+		}
+	})
+
+	manifest := exploreManifest{
+		Tool:       "netrunner-alt-gen",
+		Version:    version,
+		Algorithm:  algorithmName,
+		PrintingID: card.ID,
+		Title:      card.Attributes.Title,
+		SeedFields: renders[0].seedFields(),
+	}
+	if seedGiven {
+		manifest.SeedFields = nil
+	}
+	rec := newRenderRecord(renders[0])
+	manifest.Flags, manifest.Colors = rec.Flags, rec.Colors
+
+	var done []galleryCard
+	for i, r := range renders {
+		variant := exploreVariant{
+			Variant:     r.variant,
+			Seed:        r.seed(),
+			Fingerprint: seedFingerprint(r.seed()),
+		}
+		if errs[i] != nil {
+			variant.Error = errs[i].Error()
+		} else {
+			done = append(done, cells[i])
+		}
+		manifest.Variants = append(manifest.Variants, variant)
+	}
+	if interrupted.Err() != nil {
+		return errInterrupted
+	}
+	if len(done) == 0 {
+		return fmt.Errorf("none of the variants could be rendered")
+	}
+
+	values := fileNameValues(renders[0])
+	name := fmt.Sprintf("%s-%s-%s", values["id"], values["title"], values["algorithm"])
+	exploreDir := filepath.Join(outputDir, "explore")
+	if err := os.MkdirAll(exploreDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	gridPath := filepath.Join(exploreDir, name+".png")
+	log.Printf("writing grid %s", gridPath)
+	if err := writeContactSheet(gridPath, done, exploreColumns, thumbWidth); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestPath := filepath.Join(exploreDir, name+".json")
+	log.Printf("writing variants %s", manifestPath)
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("previewed %d of %d variants in %s, render the ones you like with --render, e.g. --render %d",
+		len(done), len(renders), time.Since(start).Round(time.Second), renders[0].variant)
+
+	return nil
+}
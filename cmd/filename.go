@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const defaultFileNameTemplate = "{id}-{set}-{position}-{title}"
+
+var fileNamePlaceholders = []string{
+	"id", "code", "set", "cycle", "position", "title",
+	"faction", "type", "algorithm", "seed", "variant", "side",
+}
+
+var placeholderRegexp = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// checkFileNameTemplate makes sure every placeholder is known and the
+// names stay inside the output directory
+func checkFileNameTemplate(tmpl string) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return fmt.Errorf("--filename-template can't be empty")
+	}
+	if filepath.IsAbs(tmpl) || strings.HasPrefix(tmpl, "/") {
+		return fmt.Errorf(`--filename-template "%s" must be relative to the output directory`, tmpl)
+	}
+
+	for _, match := range placeholderRegexp.FindAllStringSubmatch(tmpl, -1) {
+		if !slices.Contains(fileNamePlaceholders, match[1]) {
+			return fmt.Errorf(`unknown placeholder "{%s}" in --filename-template, expected one of {%s}`,
+				match[1], strings.Join(fileNamePlaceholders, "}, {"))
+		}
+	}
+
+	rest := placeholderRegexp.ReplaceAllString(tmpl, "x")
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf(`unbalanced braces in --filename-template "%s"`, tmpl)
+	}
+	for _, elem := range strings.Split(rest, "/") {
+		if elem == "." || elem == ".." {
+			return fmt.Errorf(`--filename-template "%s" can't contain "%s"`, tmpl, elem)
+		}
+	}
+
+	return nil
+}
+
+// getFileName fills in --filename-template for a card, without the
+// extension. The result can contain "/" for subdirectories of the
+// output directory, placeholder values never do. A template ending in
+// "/" puts the default name in that directory. Variants other than 0
+// get "-v" and their number when the template doesn't place it, back
+// frames get "-back" added.
+func getFileName(r cardRender) string {
+
+	tmpl := fileNameTemplate
+	if tmpl == "" {
+		tmpl = defaultFileNameTemplate
+	}
+	if strings.HasSuffix(tmpl, "/") {
+		tmpl += defaultFileNameTemplate
+	}
+	// variants would overwrite the usual art otherwise
+	if r.variant != 0 && !strings.Contains(tmpl, "{variant}") {
+		tmpl += "-v{variant}"
+	}
+
+	values := fileNameValues(r)
+	name := placeholderRegexp.ReplaceAllStringFunc(tmpl, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	})
+
+	if strings.Contains(r.frame, "back") {
+		name += "-back"
+	}
+
+	return name
+}
+
+func fileNameValues(r cardRender) map[string]string {
+
+	card, algorithm := r.card, r.algorithm
+	if algorithm == "" {
+		// empty and image don't draw art with an algorithm
+		algorithm = "none"
+		if activeCmd != nil {
+			algorithm = activeCmd.Name()
+		}
+	}
+
+	title := strings.ReplaceAll(card.Attributes.StrippedTitle, ":", "")
+	if title == "" {
+		title = card.Attributes.Title
+	}
+
+	return map[string]string{
+		"id":        fileNameID(card.ID),
+		"code":      fileNameSafe(card.Attributes.CardID),
+		"set":       fileNameSafe(card.Attributes.CardSetID),
+		"cycle":     fileNameSafe(card.Attributes.CardCycleID),
+		"position":  fmt.Sprintf("%03d", card.Attributes.PositionInSet),
+		"title":     strings.ToLower(fileNameSafe(title)),
+		"faction":   fileNameSafe(card.Attributes.FactionID),
+		"type":      fileNameSafe(card.Attributes.CardTypeID),
+		"algorithm": fileNameSafe(algorithm),
+		"seed":      seedFingerprint(r.seed()),
+		"variant":   strconv.Itoa(r.variant),
+		"side":      fileNameSafe(card.Attributes.SideID),
+	}
+}
+
+// fileNameID zero pads numeric printing IDs so files sort in printing
+// order, custom cards can have any ID
+func fileNameID(id string) string {
+	if n, err := strconv.Atoi(id); err == nil && n >= 0 {
+		return fmt.Sprintf("%05d", n)
+	}
+	return fileNameSafe(id)
+}
+
+// fileNameSafe replaces everything but letters and digits with "-",
+// values that end up empty become "unknown" so they don't collapse a
+// directory level
+func fileNameSafe(value string) string {
+	value = fileNameRegexp.ReplaceAllString(value, "-")
+	if strings.Trim(value, "-") == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// outputPath is the file for a card under dir with the given extension,
+// any subdirectories from --filename-template are created
+func outputPath(dir string, r cardRender, ext string) (string, error) {
+	path := filepath.Join(dir, getFileName(r)+ext)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+func TestCheckFileNameTemplate(t *testing.T) {
+
+	tests := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{tmpl: defaultFileNameTemplate},
+		{tmpl: "{faction}/{type}/{title}"},
+		{tmpl: "{set}/"},
+		{tmpl: "alt-{code}-{algorithm}-{seed}-{variant}-{side}-{cycle}"},
+		{tmpl: "", wantErr: true},
+		{tmpl: "   ", wantErr: true},
+		{tmpl: "/tmp/{title}", wantErr: true},
+		{tmpl: "{name}", wantErr: true},
+		{tmpl: "{title", wantErr: true},
+		{tmpl: "title}", wantErr: true},
+		{tmpl: "../{title}", wantErr: true},
+		{tmpl: "{set}/./{title}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := checkFileNameTemplate(tt.tmpl)
+		if tt.wantErr && err == nil {
+			t.Errorf("%q: expected an error", tt.tmpl)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%q: %s", tt.tmpl, err)
+		}
+	}
+}
+
+// fileName is the name of card drawn with netwalker under tmpl
+func fileName(t *testing.T, tmpl string, card *nrdb.Printing, back bool) string {
+	return variantFileName(t, tmpl, card, back, 0)
+}
+
+func variantFileName(t *testing.T, tmpl string, card *nrdb.Printing, back bool, variant int) string {
+	t.Helper()
+
+	origTemplate := fileNameTemplate
+	t.Cleanup(func() { fileNameTemplate = origTemplate })
+
+	fileNameTemplate = tmpl
+	r := newCardRender(card, "netwalker", "")
+	r.variant = variant
+	if back {
+		r = r.back()
+	}
+	return getFileName(r)
+}
+
+func fileNameCard(id, title, set string) *nrdb.Printing {
+	return &nrdb.Printing{
+		Document: nrdb.Document[nrdb.PrintingAttributes, nrdb.PrintingRelationships]{
+			ID: id,
+			Attributes: &nrdb.PrintingAttributes{
+				CardAttributes: nrdb.CardAttributes{
+					Title:         title,
+					StrippedTitle: title,
+					FactionID:     "neutral_corp",
+					CardTypeID:    "operation",
+					SideID:        "corp",
+				},
+				CardID:        "hedge_fund",
+				CardSetID:     set,
+				CardCycleID:   "core",
+				PositionInSet: 50,
+			},
+		},
+	}
+}
+
+func TestGetFileName(t *testing.T) {
+
+	hedgeFund := fileNameCard("1050", "Hedge Fund", "core")
+	zahya := fileNameCard("26001", "Zahya Sadeghi: Versatile Smuggler", "")
+	custom := fileNameCard("my card!", "Hedge Fund", "playtest")
+
+	tests := []struct {
+		tmpl    string
+		card    *nrdb.Printing
+		back    bool
+		variant int
+		want    string
+	}{
+		{tmpl: defaultFileNameTemplate, card: hedgeFund, want: "01050-core-050-hedge-fund"},
+		{tmpl: defaultFileNameTemplate, card: hedgeFund, back: true, want: "01050-core-050-hedge-fund-back"},
+		{tmpl: "{faction}/{type}/{title}", card: hedgeFund, want: "neutral-corp/operation/hedge-fund"},
+		{tmpl: "{cycle}/{set}/", card: hedgeFund, want: "core/core/01050-core-050-hedge-fund"},
+		{tmpl: "{code}-{side}-{algorithm}-{variant}", card: hedgeFund, want: "hedge-fund-corp-netwalker-0"},
+		{tmpl: "{title}", card: zahya, want: "zahya-sadeghi-versatile-smuggler"},
+		{tmpl: "{set}/{title}", card: zahya, want: "unknown/zahya-sadeghi-versatile-smuggler"},
+		{tmpl: "{id}", card: custom, want: "my-card-"},
+		{tmpl: defaultFileNameTemplate, card: hedgeFund, variant: 3, want: "01050-core-050-hedge-fund-v3"},
+		{tmpl: defaultFileNameTemplate, card: hedgeFund, back: true, variant: 3, want: "01050-core-050-hedge-fund-v3-back"},
+		{tmpl: "{set}/", card: hedgeFund, variant: 3, want: "core/01050-core-050-hedge-fund-v3"},
+		{tmpl: "{title}/{variant}", card: hedgeFund, variant: 3, want: "hedge-fund/3"},
+	}
+
+	for _, tt := range tests {
+		if got := variantFileName(t, tt.tmpl, tt.card, tt.back, tt.variant); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.tmpl, got, tt.want)
+		}
+	}
+
+	// the seed placeholder tells apart cards that draw different art
+	if fileName(t, "{seed}", hedgeFund, false) == fileName(t, "{seed}", zahya, false) {
+		t.Error("different cards have the same {seed}")
+	}
+	if fileName(t, "{seed}", hedgeFund, false) != fileName(t, "{seed}", custom, false) {
+		t.Error("cards with the same text have a different {seed}")
+	}
+	if fileName(t, "{seed}", hedgeFund, false) == variantFileName(t, "{seed}", hedgeFund, false, 1) {
+		t.Error("variants have the same {seed}")
+	}
+}
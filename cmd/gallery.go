@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mangofeet/netrunner-alt-gen/assets"
+	"github.com/mangofeet/netrunner-alt-gen/internal/metadata"
+	"github.com/spf13/cobra"
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+)
+
+var galleryCmd = &cobra.Command{
+	Use:   "gallery [output directories or generated images]",
+	Short: "Write a contact sheet and an HTML gallery of generated cards",
+	Long: `Write a contact sheet and an HTML gallery of generated cards.
+
+Directories are searched for PNG and JPEG cards, the output directory
+is used when none are given. The contact sheet is a grid of thumbnails
+with captions, the HTML page holds the thumbnails, the card details and
+the parameters each card was generated with, and links to the full
+size images. Both are written to a "gallery" directory in the output
+directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := generateGallery(args); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+const (
+	galleryPadding     = 24.0
+	galleryCaptionSize = 18.0
+	galleryCaptionGap  = 6.0
+	galleryLabelSize   = 32.0
+)
+
+var (
+	galleryBackground = color.RGBA{0x1c, 0x1c, 0x1c, 0xff}
+	galleryText       = color.RGBA{0xdc, 0xdc, 0xcc, 0xff}
+	galleryTextDim    = color.RGBA{0x9f, 0x9f, 0x8f, 0xff}
+)
+
+// galleryCard is one generated image with the record it was made with,
+// if it has one
+type galleryCard struct {
+	Path      string
+	Link      string
+	Name      string
+	Width     int
+	Height    int
+	Thumbnail image.Image
+	Record    *renderRecord
+
+	// Label is drawn in the corner of the thumbnail on the contact
+	// sheet, explore numbers its variants with it
+	Label string
+}
+
+func (card galleryCard) Title() string {
+	if card.Record == nil {
+		return card.Name
+	}
+	title := card.Record.Printing.Attributes.Title
+	if strings.Contains(card.Record.Frame, "back") {
+		title += " (back)"
+	}
+	return title
+}
+
+func (card galleryCard) Caption() string {
+	if card.Record == nil {
+		return fmt.Sprintf("%d x %d", card.Width, card.Height)
+	}
+	algorithm := card.Record.Algorithm
+	if algorithm == "" {
+		algorithm = card.Record.Command
+	}
+	if card.Record.Variant > 0 {
+		return fmt.Sprintf("%s · %s · variant %d", algorithm, card.Record.PrintingID, card.Record.Variant)
+	}
+	return fmt.Sprintf("%s · %s", algorithm, card.Record.PrintingID)
+}
+
+// ThumbnailURL is the thumbnail as a data URL, so the page works
+// without any other files
+func (card galleryCard) ThumbnailURL() (template.URL, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, card.Thumbnail, &jpeg.Options{Quality: 85}); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+func generateGallery(paths []string) error {
+
+	if galleryColumns < 1 {
+		return fmt.Errorf("--columns must be at least 1, got %d", galleryColumns)
+	}
+	if thumbWidth < 16 {
+		return fmt.Errorf("--thumb-width must be at least 16, got %d", thumbWidth)
+	}
+
+	galleryDir := filepath.Join(outputDir, "gallery")
+
+	if len(paths) == 0 {
+		paths = []string{outputDir}
+	}
+
+	files, err := findGalleryImages(paths, galleryDir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no PNG or JPEG cards found in %s", strings.Join(paths, ", "))
+	}
+
+	if err := os.MkdirAll(galleryDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	log.Printf("making thumbnails for %d cards", len(files))
+	var cards []galleryCard
+	for _, path := range files {
+		card, err := loadGalleryCard(path, galleryDir)
+		if err != nil {
+			log.Printf("skipping %s: %s", path, err)
+			continue
+		}
+		cards = append(cards, card)
+	}
+	if len(cards) == 0 {
+		return errors.New("none of the cards could be read")
+	}
+
+	// sheets from an earlier run would be left over when there are
+	// fewer now
+	oldSheets, _ := filepath.Glob(filepath.Join(galleryDir, "contact-sheet*.png"))
+	for _, path := range oldSheets {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	perSheet := galleryPerSheet
+	if perSheet <= 0 {
+		perSheet = len(cards)
+	}
+	sheets := (len(cards) + perSheet - 1) / perSheet
+	for i := 0; i < sheets; i++ {
+		name := "contact-sheet.png"
+		if sheets > 1 {
+			name = fmt.Sprintf("contact-sheet-%02d.png", i+1)
+		}
+		sheetPath := filepath.Join(galleryDir, name)
+		log.Printf("writing contact sheet %s", sheetPath)
+		if err := writeContactSheet(sheetPath, cards[i*perSheet:min((i+1)*perSheet, len(cards))], galleryColumns, thumbWidth); err != nil {
+			return err
+		}
+	}
+
+	htmlPath := filepath.Join(galleryDir, "index.html")
+	log.Printf("writing gallery %s", htmlPath)
+	return writeGalleryHTML(htmlPath, cards)
+}
+
+// findGalleryImages lists the PNG and JPEG files in paths, in name
+// order. Layer directories and the gallery itself are skipped.
+func findGalleryImages(paths []string, galleryDir string) ([]string, error) {
+
+	var files []string
+	skipped := 0
+
+	isImage := func(path string) bool {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".png", ".jpg", ".jpeg":
+			return true
+		case ".svg", ".pdf", ".tiff", ".tif":
+			skipped++
+		}
+		return false
+	}
+
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if !isImage(root) {
+				return nil, fmt.Errorf("%s: the gallery only shows PNG and JPEG files", root)
+			}
+			files = append(files, root)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if filepath.Clean(path) == filepath.Clean(galleryDir) {
+					return filepath.SkipDir
+				}
+				// layer directories hold parts of a card
+				if _, err := os.Stat(filepath.Join(path, "manifest.json")); err == nil {
+					return filepath.SkipDir
+				}
+				if strings.HasSuffix(path, framesSuffix) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(strings.TrimSuffix(path, filepath.Ext(path)), animationSuffix) {
+				return nil
+			}
+			if isImage(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if skipped > 0 {
+		log.Printf("skipping %d SVG, PDF and TIFF files, the gallery only shows PNG and JPEG", skipped)
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+func loadGalleryCard(path, galleryDir string) (galleryCard, error) {
+
+	card := galleryCard{
+		Path: path,
+		Name: filepath.Base(path),
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return card, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return card, err
+	}
+	card.Width, card.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	card.Thumbnail = thumbnail(img, thumbWidth)
+
+	card.Link = filepath.ToSlash(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		if absGallery, err := filepath.Abs(galleryDir); err == nil {
+			if rel, err := filepath.Rel(absGallery, abs); err == nil {
+				card.Link = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	// JPEG files don't carry the parameters
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		rec, err := readRenderRecord(path)
+		switch {
+		case err == nil:
+			card.Record = &rec
+		case errors.Is(err, metadata.ErrNotFound):
+			// made by an older version
+		default:
+			log.Printf("warning: %s", err)
+		}
+	}
+
+	return card, nil
+}
+
+// thumbnail scales img down to width pixels wide
+func thumbnail(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := int(math.Round(float64(width) * float64(bounds.Dy()) / float64(bounds.Dx())))
+
+	cnv := canvas.New(float64(width), float64(height))
+	ctx := canvas.NewContext(cnv)
+	ctx.DrawImage(0, 0, img, canvas.DPMM(float64(bounds.Dx())/float64(width)))
+
+	return rasterizer.Draw(cnv, canvas.DPMM(1), canvas.DefaultColorSpace)
+}
+
+// writeContactSheet draws the thumbnails in a grid of columns with
+// their title and algorithm below them, one canvas unit is one pixel.
+// Thumbnails are at most width pixels wide.
+func writeContactSheet(path string, cards []galleryCard, columns, width int) error {
+
+	family, err := galleryFonts()
+	if err != nil {
+		return err
+	}
+	// font sizes are in points, the canvas is in pixels
+	ptPerPx := 72 / 25.4
+	titleFace := family.Face(galleryCaptionSize*ptPerPx, galleryText, canvas.FontBold)
+	captionFace := family.Face(galleryCaptionSize*0.8*ptPerPx, galleryTextDim, canvas.FontRegular)
+	labelFace := family.Face(galleryLabelSize*ptPerPx, galleryText, canvas.FontBold)
+
+	captionHeight := galleryCaptionGap + galleryCaptionSize*1.3 + galleryCaptionSize*0.8*1.3
+
+	columns = min(columns, len(cards))
+	var rowHeights []float64
+	for i, card := range cards {
+		if i%columns == 0 {
+			rowHeights = append(rowHeights, 0)
+		}
+		row := len(rowHeights) - 1
+		rowHeights[row] = math.Max(rowHeights[row], float64(card.Thumbnail.Bounds().Dy())+captionHeight)
+	}
+
+	cellWidth := float64(width) + galleryPadding
+	sheetWidth := float64(columns)*cellWidth + galleryPadding
+	sheetHeight := galleryPadding
+	for _, h := range rowHeights {
+		sheetHeight += h + galleryPadding
+	}
+
+	cnv := canvas.New(sheetWidth, sheetHeight)
+	ctx := canvas.NewContext(cnv)
+	ctx.SetFillColor(galleryBackground)
+	ctx.DrawPath(0, 0, canvas.Rectangle(sheetWidth, sheetHeight))
+
+	top := sheetHeight - galleryPadding
+	for i, card := range cards {
+		col, row := i%columns, i/columns
+		if col == 0 && row > 0 {
+			top -= rowHeights[row-1] + galleryPadding
+		}
+
+		x := galleryPadding + float64(col)*cellWidth
+		thumbHeight := float64(card.Thumbnail.Bounds().Dy())
+		ctx.DrawImage(x, top-thumbHeight, card.Thumbnail, canvas.DPMM(1))
+
+		if card.Label != "" {
+			label := canvas.NewTextLine(labelFace, card.Label, canvas.Left)
+			box := label.Bounds()
+			ctx.SetFillColor(galleryBackground)
+			ctx.DrawPath(x, top-box.H-galleryCaptionGap*2, canvas.Rectangle(box.W+galleryCaptionGap*2, box.H+galleryCaptionGap*2))
+			ctx.DrawText(x+galleryCaptionGap, top-galleryCaptionGap-galleryLabelSize*0.8, label)
+		}
+
+		y := top - thumbHeight - galleryCaptionGap - galleryCaptionSize
+		ctx.DrawText(x, y, canvas.NewTextLine(titleFace, fitText(titleFace, card.Title(), float64(width)), canvas.Left))
+		y -= galleryCaptionSize * 1.2
+		ctx.DrawText(x, y, canvas.NewTextLine(captionFace, fitText(captionFace, card.Caption(), float64(width)), canvas.Left))
+	}
+
+	return renderers.Write(path, cnv, canvas.DPMM(1))
+}
+
+// fitText shortens s with an ellipsis until it is at most width wide
+func fitText(face *canvas.FontFace, s string, width float64) string {
+	if face.TextWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && face.TextWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func galleryFonts() (*canvas.FontFamily, error) {
+	family := canvas.NewFontFamily("gallery")
+	for style, name := range map[canvas.FontStyle]string{
+		canvas.FontRegular: "Ubuntu-R.ttf",
+		canvas.FontBold:    "Ubuntu-B.ttf",
+	} {
+		f, err := assets.FS.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := family.LoadFont(data, 0, style); err != nil {
+			return nil, err
+		}
+	}
+	return family, nil
+}
+
+func writeGalleryHTML(path string, cards []galleryCard) error {
+
+	tmpl, err := template.ParseFS(assets.FS, "gallery.html")
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, map[string]any{
+		"Cards":     cards,
+		"Generated": time.Now().Format(time.DateTime),
+		"Software":  fmt.Sprintf("netrunner-alt-gen %s", version),
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -1,124 +1,357 @@
 package cmd
 
 import (
-	"fmt"
+	"bufio"
 	"image/color"
-	"log"
+	"image/jpeg"
 	"os"
+	"path/filepath"
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
+	"github.com/mangofeet/netrunner-alt-gen/internal/metadata"
+	"github.com/mangofeet/netrunner-alt-gen/internal/pngstream"
+	"github.com/mangofeet/netrunner-alt-gen/layout"
 	"github.com/mangofeet/nrdb-go"
 	"github.com/tdewolff/canvas"
 	"github.com/tdewolff/canvas/renderers"
-	"github.com/tdewolff/canvas/renderers/rasterizer"
+	"github.com/tdewolff/canvas/renderers/pdf"
+	"golang.org/x/image/tiff"
 )
 
+// cardRender is a card being rendered, what draws its art and the
+// frame that goes over it. The frame is kept here rather than changing
+// --frame so a card and its back, or the cards of a parallel batch,
+// don't get in each other's way.
+type cardRender struct {
+	card                       *nrdb.Printing
+	algorithm, designer, frame string
+	variant                    int
+	// effectiveSeed replaces the seed worked out from the flags and
+	// the card, reproduce uses the recorded one
+	effectiveSeed string
+}
+
+func newCardRender(card *nrdb.Printing, algorithm, designer string) cardRender {
+	return cardRender{
+		card:      card,
+		algorithm: algorithm,
+		designer:  designer,
+		frame:     frame,
+		variant:   seedVariant,
+	}
+}
+
+// back is the same card with the "${frame}-back" frame
+func (r cardRender) back() cardRender {
+	r.frame += "-back"
+	return r
+}
+
 func generateCard(drawer art.Drawer, card *nrdb.Printing, algorithm, designer string) error {
+	return generateRender(drawer, newCardRender(card, algorithm, designer))
+}
+
+// generateRender draws and writes a card, with its back and animation
+// when they're asked for
+func generateRender(drawer art.Drawer, r cardRender) error {
 
 	cnv := canvas.New(canvasWidth, canvasHeight)
 
 	ctx := canvas.NewContext(cnv)
 
-	if err := drawer.Draw(ctx, card); err != nil {
+	var anim *animator
+	if animateFormat != "none" {
+		var err error
+		if drawer, anim, err = newAnimator(drawer, cnv, r); err != nil {
+			return err
+		}
+		ctx = canvas.NewContext(anim)
+	}
+
+	if err := drawArt(drawer, ctx, r); err != nil {
 		return err
 	}
 
+	if anim != nil {
+		if err := anim.write(r); err != nil {
+			return err
+		}
+		// the frame goes on the card canvas only
+		ctx = canvas.NewContext(cnv)
+	}
+
 	var backCnv *canvas.Canvas
 	if makeBack {
 		backCnv = canvas.New(canvasWidth, canvasHeight)
 		cnv.RenderTo(backCnv)
 	}
 
-	if err := output(cnv, ctx, card, algorithm, designer); err != nil {
+	if err := output(cnv, ctx, r); err != nil {
 		return err
 	}
 
 	if makeBack {
-		frame = frame + "-back"
-		output(backCnv, canvas.NewContext(backCnv), card, algorithm, designer)
+		if err := output(backCnv, canvas.NewContext(backCnv), r.back()); err != nil {
+			return err
+		}
 	}
 
 	return nil
 
 }
 
-func generateCardCanvas(drawer art.Drawer, card *nrdb.Printing, algorithm, designer string) (*canvas.Canvas, error) {
+// generateCardCanvas draws a card with its frame. With withBack the
+// card back is drawn over a copy of the same art, like --make-back
+// does, otherwise back is nil.
+func generateCardCanvas(drawer art.Drawer, card *nrdb.Printing, algorithm, designer string, withBack bool) (front, back *canvas.Canvas, err error) {
+	return renderCardCanvas(drawer, newCardRender(card, algorithm, designer), withBack)
+}
+
+func renderCardCanvas(drawer art.Drawer, r cardRender, withBack bool) (front, back *canvas.Canvas, err error) {
 	cnv := canvas.New(canvasWidth, canvasHeight)
 	ctx := canvas.NewContext(cnv)
 
-	if err := drawer.Draw(ctx, card); err != nil {
-		return nil, err
+	if err := drawArt(drawer, ctx, r); err != nil {
+		return nil, nil, err
 	}
 
-	if err := drawFrame(cnv, ctx, card, algorithm, designer); err != nil {
-		return nil, err
+	if withBack {
+		back = canvas.New(canvasWidth, canvasHeight)
+		cnv.RenderTo(back)
+
+		if err := drawFrame(back, canvas.NewContext(back), r.back()); err != nil {
+			return nil, nil, err
+		}
+		if drawMarginLines {
+			drawGuides(canvas.NewContext(back))
+		}
+	}
+
+	if err := drawFrame(cnv, ctx, r); err != nil {
+		return nil, nil, err
 	}
 
 	if drawMarginLines {
-		marginX := (canvasWidth - cardWidth) / 2
-		marginY := (canvasHeight - cardHeight) / 2
-		safeMarginX := (canvasWidth - safeWidth) / 2
-		safeMarginY := (canvasHeight - safeHeight) / 2
+		drawGuides(ctx)
+	}
 
-		drawMargin(ctx, marginX, marginY, cardWidth, cardHeight, color.White)
-		drawMargin(ctx, safeMarginX, safeMarginY, safeWidth, safeHeight, canvas.Red)
+	return cnv, back, nil
+}
+
+func drawFrame(cnv *canvas.Canvas, ctx *canvas.Context, r cardRender) error {
+	frameCnv, err := renderFrame(r)
+	if err != nil {
+		return err
 	}
 
-	return cnv, nil
+	placeFrame(cnv, ctx, frameCnv)
+
+	return nil
 }
 
-func drawFrame(cnv *canvas.Canvas, ctx *canvas.Context, card *nrdb.Printing, algorithm, designer string) error {
-	if frame == "none" {
-		return nil
+// renderFrame draws the frame on its own transparent canvas, it
+// returns nil when the frame is "none"
+func renderFrame(r cardRender) (*canvas.Canvas, error) {
+	if r.frame == "none" {
+		return nil, nil
 	}
-	framer, err := getFramer(card, algorithm, designer)
+	framer, err := getFramer(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	frameCnv := canvas.New(canvasWidth, canvasHeight)
 	frameCtx := canvas.NewContext(frameCnv)
 
-	if err := framer.Draw(frameCtx, card); err != nil {
+	if err := framer.Draw(frameCtx, r.card); err != nil {
+		return nil, err
+	}
+
+	return frameCnv, nil
+}
+
+func placeFrame(cnv *canvas.Canvas, ctx *canvas.Context, frameCnv *canvas.Canvas) {
+	if frameCnv == nil {
+		return
+	}
+
+	if isVectorFormat() {
+		// copy the frame over as paths and text
+		frameCnv.RenderTo(cnv)
+		return
+	}
+
+	frameImg := layout.RasterizeTiled(frameCnv)
+
+	ctx.RenderImage(frameImg, layout.ImageMatrix())
+}
+
+// drawGuides draws the cut line in white and the safe area in red
+func drawGuides(ctx *canvas.Context) {
+	marginX := (canvasWidth - cardWidth) / 2
+	marginY := (canvasHeight - cardHeight) / 2
+	safeMarginX := (canvasWidth - safeWidth) / 2
+	safeMarginY := (canvasHeight - safeHeight) / 2
+
+	drawMargin(ctx, marginX, marginY, cardWidth, cardHeight, color.White)
+	drawMargin(ctx, safeMarginX, safeMarginY, safeWidth, safeHeight, canvas.Red)
+}
+
+func output(cnv *canvas.Canvas, ctx *canvas.Context, r cardRender) error {
+
+	frameCnv, err := renderFrame(r)
+	if err != nil {
 		return err
 	}
 
-	frameImg := rasterizer.Draw(frameCnv, canvas.DPMM(1), canvas.DefaultColorSpace)
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	if layerFormat != "none" {
+		if err := writeLayers(r, cnv, frameCnv); err != nil {
+			return err
+		}
+	}
+
+	if isVectorFormat() {
+		// the art can be hundreds of thousands of paths, only the
+		// frame is kept as vectors
+		artImg := layout.Rasterize(cnv)
+		cnv = canvas.New(canvasWidth, canvasHeight)
+		ctx = canvas.NewContext(cnv)
+		ctx.RenderImage(artImg, layout.ImageMatrix())
+	}
 
-	ctx.RenderImage(frameImg, canvas.Identity)
+	placeFrame(cnv, ctx, frameCnv)
+
+	if drawMarginLines {
+		drawGuides(ctx)
+	}
+
+	filename, err := outputPath(outputDir, r, "."+outputFormat)
+	if err != nil {
+		return err
+	}
+	rec := newRenderRecord(r)
+
+	if isCropped() {
+		if keepFullBleed {
+			r.logger().Printf("rendering full bleed output to %s", filename)
+			if err := writeCanvas(filename, cnv, rec); err != nil {
+				return err
+			}
+
+			filename, err = outputPath(filepath.Join(outputDir, "cropped"), r, "."+outputFormat)
+			if err != nil {
+				return err
+			}
+		}
+		cnv = cropCanvas(cnv)
+	}
+
+	r.logger().Printf("rendering output to %s", filename)
+	if err := writeCanvas(filename, cnv, rec); err != nil {
+		return err
+	}
+	r.logger().Println("done")
 
 	return nil
+}
+
+var outputFormats = []string{"png", "jpg", "tiff", "svg", "pdf"}
 
+func isVectorFormat() bool {
+	return outputFormat == "svg" || outputFormat == "pdf"
 }
 
-func output(cnv *canvas.Canvas, ctx *canvas.Context, card *nrdb.Printing, algorithm, designer string) error {
+// writeCanvas writes the canvas in the format matching the file
+// extension. Raster formats are rendered at --dpi, vector formats are
+// scaled to the physical card size. PNG, SVG and PDF files get the
+// render parameters in their metadata.
+func writeCanvas(filename string, cnv *canvas.Canvas, rec renderRecord) error {
 
-	if err := drawFrame(cnv, ctx, card, algorithm, designer); err != nil {
+	params, err := rec.encode()
+	if err != nil {
 		return err
 	}
 
-	if drawMarginLines {
+	if !isVectorFormat() {
+		return writeRaster(filename, cnv, rec, params)
+	}
 
-		marginX := (canvasWidth - cardWidth) / 2
-		marginY := (canvasHeight - cardHeight) / 2
-		safeMarginX := (canvasWidth - safeWidth) / 2
-		safeMarginY := (canvasHeight - safeHeight) / 2
+	mmPerUnit := layout.MM(1)
+	scaled := canvas.New(cnv.W*mmPerUnit, cnv.H*mmPerUnit)
+	cnv.RenderViewTo(scaled, canvas.Identity.Scale(mmPerUnit, mmPerUnit))
 
-		drawMargin(ctx, marginX, marginY, cardWidth, cardHeight, color.White)
-		drawMargin(ctx, safeMarginX, safeMarginY, safeWidth, safeHeight, canvas.Red)
+	if outputFormat == "pdf" {
+		return writePDF(filename, scaled, rec, params)
+	}
 
+	if err := renderers.Write(filename, scaled); err != nil {
+		return err
 	}
+	return metadata.AddSVG(filename, metadataKey, params)
+}
 
-	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+// writeRaster writes a PNG a band of rows at a time, so a card at any
+// DPI only needs a band of it in memory. JPEG and TIFF are encoded from
+// the whole image.
+func writeRaster(filename string, cnv *canvas.Canvas, rec renderRecord, params string) error {
+	f, err := os.Create(filename)
+	if err != nil {
 		return err
 	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	switch outputFormat {
+	case "png":
+		size := layout.RasterSize(cnv)
+		enc, err := pngstream.NewEncoder(w, size.X, size.Y, metadata.PNGChunks(
+			metadata.Entry{Key: "Title", Value: rec.Printing.Attributes.Title},
+			metadata.Entry{Key: "Software", Value: rec.software()},
+			metadata.Entry{Key: metadataKey, Value: params},
+		))
+		if err != nil {
+			return err
+		}
+		if err := layout.RasterizeBands(cnv, enc.WriteRows); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	case "jpg":
+		if err := jpeg.Encode(w, layout.Rasterize(cnv), nil); err != nil {
+			return err
+		}
+	case "tiff":
+		if err := tiff.Encode(w, layout.Rasterize(cnv), nil); err != nil {
+			return err
+		}
+	}
 
-	filename := fmt.Sprintf("%s/%s.png", outputDir, getFileName(card))
-	log.Printf("rendering output to %s", filename)
-	if err := renderers.Write(filename, cnv, canvas.DPMM(1)); err != nil {
+	if err := w.Flush(); err != nil {
 		return err
 	}
-	log.Println("done")
+	return f.Close()
+}
 
-	return nil
+func writePDF(filename string, cnv *canvas.Canvas, rec renderRecord, params string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	p := pdf.New(f, cnv.W, cnv.H, nil)
+	p.SetInfo(rec.Printing.Attributes.Title, "", metadata.PDFKeywords(metadataKey, params), rec.Designer, rec.software())
+	cnv.RenderTo(p)
+	if err := p.Close(); err != nil {
+		return err
+	}
+
+	return f.Close()
 }
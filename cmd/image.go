@@ -14,7 +14,7 @@ import (
 
 var imageCmd = &cobra.Command{
 	Use:   "image [path to image] [card name or printing ID]",
-	Args:  cobra.MinimumNArgs(2),
+	Args:  cardArgs(2),
 	Short: `Generate a frame for the named card over the specified image file`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -30,16 +30,12 @@ var imageCmd = &cobra.Command{
 }
 
 func generateCardImage(filename, cardName string) error {
-	printing, err := getCardData(cardName)
-	if err != nil {
-		return err
-	}
-	log.Printf("generating %s", printing.Attributes.StrippedTitle)
-
-	drawer := imageDrawer{
-		filename: filename,
-	}
-	return generateCard(drawer, printing, "", designer)
+	return forEachCard(cardName, "image", func(printing *nrdb.Printing) error {
+		drawer := imageDrawer{
+			filename: filename,
+		}
+		return generateCard(drawer, printing, "", designer)
+	})
 }
 
 type imageDrawer struct {
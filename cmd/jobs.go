@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mangofeet/netrunner-alt-gen/layout"
+)
+
+const (
+	// a card holds about this many rasters at its peak, the tech rings
+	// keep several layers and masks around. They're the size of the
+	// card or of a band, when the card is bigger than one.
+	cardImagesAtPeak = 8
+	// the paths of the art before it's rasterized, 10000 walkers
+	// take a few hundred MB
+	cardPathBytes = 512 << 20
+)
+
+// cardMemory estimates the memory one card needs while rendering at
+// the current --dpi
+func cardMemory() uint64 {
+	px := layout.Resolution().DPMM()
+	pixels := math.Round(canvasWidth*px) * math.Round(canvasHeight*px)
+	imageBytes := math.Min(pixels, float64(layout.BandPixels)) * 4 * cardImagesAtPeak
+	// only PNGs are written a band at a time
+	if !isVectorFormat() && outputFormat != "png" {
+		imageBytes += pixels * 4
+	}
+	return uint64(imageBytes) + cardPathBytes
+}
+
+// batchJobs works out how many cards of a batch of n to render at
+// once: --jobs, or one per CPU with --jobs 0, as many as fit in
+// --max-memory or the available memory
+func batchJobs(n int) (int, error) {
+	jobs := maxJobs
+	if jobs < 0 {
+		return 0, fmt.Errorf("--jobs can't be negative, got %d", jobs)
+	}
+	if jobs == 0 {
+		jobs = runtime.NumCPU()
+	}
+	jobs = min(jobs, n)
+	if jobs <= 1 {
+		return 1, nil
+	}
+
+	limit, err := memoryLimit()
+	if err != nil {
+		return 0, err
+	}
+	if limit == 0 {
+		return jobs, nil
+	}
+
+	perCard := cardMemory()
+	if fit := int(limit / perCard); fit < jobs {
+		log.Printf("rendering %d cards at once instead of %d, each needs about %s at %g DPI and %s is available", max(fit, 1), jobs, formatBytes(perCard), layout.DPI, formatBytes(limit))
+		jobs = max(fit, 1)
+	}
+
+	return jobs, nil
+}
+
+// memoryLimit is --max-memory, which is also handed to the garbage
+// collector, or the memory the system has available. It's 0 when
+// neither is known.
+func memoryLimit() (uint64, error) {
+	if maxMemory != "" {
+		limit, err := parseBytes(maxMemory)
+		if err != nil {
+			return 0, fmt.Errorf("--max-memory: %w", err)
+		}
+		debug.SetMemoryLimit(int64(limit))
+		return limit, nil
+	}
+
+	return availableMemory(), nil
+}
+
+// availableMemory reads MemAvailable from /proc/meminfo, it returns 0
+// on systems without one
+func availableMemory() uint64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "MemAvailable:" && fields[2] == "kB" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb << 10
+		}
+	}
+	return 0
+}
+
+var byteUnits = []string{"B", "K", "M", "G", "T"}
+
+// parseBytes reads sizes like 512M, 8G or 8GB, plain numbers are bytes
+func parseBytes(size string) (uint64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	value = strings.TrimSuffix(value, "IB")
+	if len(value) > 1 && strings.HasSuffix(value, "B") {
+		value = strings.TrimSuffix(value, "B")
+	}
+
+	multiplier := 1.0
+	for i, unit := range byteUnits[1:] {
+		if strings.HasSuffix(value, unit) {
+			multiplier = math.Pow(1024, float64(i+1))
+			value = strings.TrimSuffix(value, unit)
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf(`invalid size "%s", expected something like 512M or 8G`, size)
+	}
+	return uint64(n * multiplier), nil
+}
+
+func formatBytes(n uint64) string {
+	size := float64(n)
+	unit := 0
+	for size >= 1024 && unit < len(byteUnits)-1 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f%s", size, byteUnits[unit])
+}
+
+// parallel calls fn for 0 to n-1, running up to jobs of them at once.
+// While more than one runs the cards log with their title in front.
+func parallel(n, jobs int, fn func(i int)) {
+	if jobs <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	logCardNames = true
+	defer func() { logCardNames = false }()
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestParallelLogsCardNames(t *testing.T) {
+
+	var out bytes.Buffer
+	origOutput, origFlags := log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		log.SetOutput(origOutput)
+		log.SetFlags(origFlags)
+	})
+	log.SetOutput(&out)
+	log.SetFlags(0)
+
+	renders := make([]cardRender, 3)
+	for i, title := range []string{"Hedge Fund", "Cleaver", "Ice Wall"} {
+		card := seedCard()
+		card.Attributes.StrippedTitle = title
+		renders[i] = newCardRender(card, "netwalker", "")
+	}
+	renders[2].variant = 4
+
+	parallel(len(renders), 2, func(i int) {
+		renders[i].logger().Println("finished 10 walkers")
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for _, want := range []string{"Hedge Fund: finished 10 walkers", "Cleaver: finished 10 walkers", "Ice Wall variant 4: finished 10 walkers"} {
+		found := false
+		for _, line := range lines {
+			found = found || line == want
+		}
+		if !found {
+			t.Errorf("no line %q in %q", want, lines)
+		}
+	}
+
+	out.Reset()
+	parallel(1, 1, func(i int) {
+		renders[i].logger().Println("done")
+	})
+	if got := out.String(); got != "done\n" {
+		t.Errorf("a card on its own logged %q, want no title in front", got)
+	}
+}
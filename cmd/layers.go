@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mangofeet/netrunner-alt-gen/layout"
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+)
+
+var layerFormats = []string{"none", "png", "ora"}
+
+// layerManifest describes the layers of one card so they can be
+// put back together in an image editor
+type layerManifest struct {
+	Card      layerCard   `json:"card"`
+	Algorithm string      `json:"algorithm"`
+	Designer  string      `json:"designer,omitempty"`
+	Frame     string      `json:"frame"`
+	Width     int         `json:"width"`
+	Height    int         `json:"height"`
+	DPI       float64     `json:"dpi"`
+	Trim      layerRect   `json:"trim"`
+	Safe      layerRect   `json:"safe"`
+	Layers    []layerInfo `json:"layers"`
+}
+
+type layerCard struct {
+	PrintingID string `json:"printing_id"`
+	CardID     string `json:"card_id"`
+	Title      string `json:"title"`
+	Set        string `json:"set"`
+	Cycle      string `json:"cycle,omitempty"`
+	Position   int    `json:"position"`
+	Faction    string `json:"faction"`
+	Type       string `json:"type"`
+	Side       string `json:"side"`
+}
+
+// layerInfo is one layer, in pixels from the top left corner
+type layerInfo struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Visible bool   `json:"visible"`
+}
+
+type layerRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type layer struct {
+	info layerInfo
+	img  image.Image
+}
+
+// writeLayers writes the art, frame and guides as separate images,
+// either as PNG files with a manifest.json in a directory named after
+// the card or as an OpenRaster file with the manifest next to it
+func writeLayers(r cardRender, artCnv, frameCnv *canvas.Canvas) error {
+
+	card := r.card
+
+	guidesCnv := canvas.New(canvasWidth, canvasHeight)
+	drawGuides(canvas.NewContext(guidesCnv))
+
+	// bottom to top
+	canvases := []struct {
+		name    string
+		cnv     *canvas.Canvas
+		visible bool
+	}{
+		{"art", artCnv, true},
+		{"frame", frameCnv, true},
+		{"guides", guidesCnv, drawMarginLines},
+	}
+
+	var layers []layer
+	for _, c := range canvases {
+		if c.cnv == nil {
+			continue
+		}
+		img := layout.Rasterize(c.cnv)
+		bounds := img.Bounds()
+		layers = append(layers, layer{
+			info: layerInfo{
+				Name:    c.name,
+				File:    c.name + ".png",
+				Width:   bounds.Dx(),
+				Height:  bounds.Dy(),
+				Visible: c.visible,
+			},
+			img: img,
+		})
+	}
+
+	manifest := layerManifest{
+		Card: layerCard{
+			PrintingID: card.ID,
+			CardID:     card.Attributes.CardID,
+			Title:      card.Attributes.Title,
+			Set:        card.Attributes.CardSetID,
+			Cycle:      card.Attributes.CardCycleID,
+			Position:   card.Attributes.PositionInSet,
+			Faction:    card.Attributes.FactionID,
+			Type:       card.Attributes.CardTypeID,
+			Side:       card.Attributes.SideID,
+		},
+		Algorithm: r.algorithm,
+		Designer:  r.designer,
+		Frame:     r.frame,
+		Width:     layers[0].info.Width,
+		Height:    layers[0].info.Height,
+		DPI:       layout.DPI,
+		Trim:      pixelRect(centeredRect(cardWidth, cardHeight)),
+		Safe:      pixelRect(centeredRect(safeWidth, safeHeight)),
+	}
+	for _, l := range layers {
+		manifest.Layers = append(manifest.Layers, l.info)
+	}
+
+	if layerFormat == "ora" {
+		oraPath, err := outputPath(outputDir, r, ".ora")
+		if err != nil {
+			return err
+		}
+		r.logger().Printf("writing layers to %s", oraPath)
+		if err := writeORA(oraPath, manifest, layers); err != nil {
+			return err
+		}
+		return writeManifest(strings.TrimSuffix(oraPath, ".ora")+".json", manifest)
+	}
+
+	layerDir, err := outputPath(outputDir, r, "")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(layerDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	r.logger().Printf("writing layers to %s", layerDir)
+	for _, l := range layers {
+		if err := writePNG(filepath.Join(layerDir, l.info.File), l.img); err != nil {
+			return err
+		}
+	}
+
+	return writeManifest(filepath.Join(layerDir, "manifest.json"), manifest)
+}
+
+// pixelRect converts a canvas rectangle to output pixels measured from
+// the top left, the way image editors do
+func pixelRect(rect canvas.Rect) layerRect {
+	px := float64(layout.Resolution())
+	return layerRect{
+		X:      int(math.Round(rect.X * px)),
+		Y:      int(math.Round((canvasHeight - rect.Y - rect.H) * px)),
+		Width:  int(math.Round(rect.W * px)),
+		Height: int(math.Round(rect.H * px)),
+	}
+}
+
+func writeManifest(path string, manifest layerManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// OpenRaster stack, the first layer listed is the top one
+type oraImage struct {
+	XMLName xml.Name   `xml:"image"`
+	Version string     `xml:"version,attr"`
+	W       int        `xml:"w,attr"`
+	H       int        `xml:"h,attr"`
+	XRes    int        `xml:"xres,attr"`
+	YRes    int        `xml:"yres,attr"`
+	Layers  []oraLayer `xml:"stack>layer"`
+}
+
+type oraLayer struct {
+	Name       string `xml:"name,attr"`
+	Src        string `xml:"src,attr"`
+	X          int    `xml:"x,attr"`
+	Y          int    `xml:"y,attr"`
+	Visibility string `xml:"visibility,attr"`
+}
+
+// writeORA writes an OpenRaster file, a zip holding the layer PNGs, a
+// stack.xml describing them, a merged image and a thumbnail
+func writeORA(path string, manifest layerManifest, layers []layer) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	// the mimetype has to be the first file and uncompressed
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "image/openraster"); err != nil {
+		return err
+	}
+
+	stack := oraImage{
+		Version: "0.0.5",
+		W:       manifest.Width,
+		H:       manifest.Height,
+		XRes:    int(math.Round(manifest.DPI)),
+		YRes:    int(math.Round(manifest.DPI)),
+	}
+
+	merged := canvas.New(canvasWidth, canvasHeight)
+	mergedCtx := canvas.NewContext(merged)
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		visibility := "hidden"
+		if l.info.Visible {
+			visibility = "visible"
+		}
+		stack.Layers = append(stack.Layers, oraLayer{
+			Name:       l.info.Name,
+			Src:        "data/" + l.info.File,
+			X:          l.info.X,
+			Y:          l.info.Y,
+			Visibility: visibility,
+		})
+	}
+
+	for _, l := range layers {
+		if err := zipPNG(zw, "data/"+l.info.File, l.img); err != nil {
+			return err
+		}
+		if l.info.Visible {
+			mergedCtx.RenderImage(l.img, layout.ImageMatrix())
+		}
+	}
+
+	w, err = zw.Create("stack.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(stack); err != nil {
+		return err
+	}
+
+	if err := zipPNG(zw, "mergedimage.png", layout.Rasterize(merged)); err != nil {
+		return err
+	}
+
+	// thumbnails are at most 256px on the long side
+	thumbRes := canvas.Resolution(256 / math.Max(canvasWidth, canvasHeight))
+	thumb := rasterizer.Draw(merged, thumbRes, canvas.DefaultColorSpace)
+	if err := zipPNG(zw, "Thumbnails/thumbnail.png", thumb); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func zipPNG(zw *zip.Writer, name string, img image.Image) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+	return nil
+}
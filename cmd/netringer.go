@@ -5,13 +5,15 @@ import (
 	"os"
 	"strings"
 
+	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/art/netringer"
+	"github.com/mangofeet/nrdb-go"
 	"github.com/spf13/cobra"
 )
 
 var netringerCmd = &cobra.Command{
 	Use:   "netringer [card name or printing ID]",
-	Args:  cobra.MinimumNArgs(1),
+	Args:  cardArgs(1),
 	Short: `Generate a card using the "netringer" algorithm`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -26,13 +28,13 @@ var netringerCmd = &cobra.Command{
 }
 
 func generateCardNetringer(cardName string) error {
-	printing, err := getCardData(cardName)
-	if err != nil {
-		return err
-	}
-	log.Printf("generating %s", printing.Attributes.StrippedTitle)
+	return forEachCard(cardName, "netringer", func(printing *nrdb.Printing) error {
+		return generateCard(netringerDrawer(), printing, "netringer", "mangofeet")
+	})
+}
 
-	ns := netringer.NetRinger{
+func netringerDrawer() art.Drawer {
+	return netringer.NetRinger{
 		Color:     parseColor(baseColor),
 		ColorBG:   parseColor(colorBG),
 		AltColor1: parseColor(altColor1),
@@ -40,6 +42,4 @@ func generateCardNetringer(cardName string) error {
 		AltColor3: parseColor(altColor3),
 		AltColor4: parseColor(altColor4),
 	}
-
-	return generateCard(ns, printing, "netringer", "mangofeet")
 }
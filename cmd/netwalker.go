@@ -5,13 +5,15 @@ import (
 	"os"
 	"strings"
 
+	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/art/netwalker"
+	"github.com/mangofeet/nrdb-go"
 	"github.com/spf13/cobra"
 )
 
 var netwalkerCmd = &cobra.Command{
 	Use:   "netwalker [card name or printing ID]",
-	Args:  cobra.MinimumNArgs(1),
+	Args:  cardArgs(1),
 	Short: `Generate a card using the "netwalker" algorithm`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -26,18 +28,18 @@ var netwalkerCmd = &cobra.Command{
 }
 
 func generateCardNetwalker(cardName string) error {
-	printing, err := getCardData(cardName)
-	if err != nil {
-		return err
-	}
-	log.Printf("generating %s", printing.Attributes.StrippedTitle)
+	return forEachCard(cardName, "netwalker", func(printing *nrdb.Printing) error {
+		return generateCard(netwalkerDrawer(), printing, "netwalker", "mangofeet")
+	})
+}
 
+func netwalkerDrawer() art.Drawer {
 	var nGridP *float64
 	if gridPercent >= 0 {
 		nGridP = &gridPercent
 	}
 
-	ns := netwalker.NetWalker{
+	return netwalker.NetWalker{
 		MinWalkers:   walkersMin,
 		MaxWalkers:   walkersMax,
 		GridPercent:  nGridP,
@@ -51,7 +53,18 @@ func generateCardNetwalker(cardName string) error {
 		GridColor2:   parseColor(gridColor2),
 		GridColor3:   parseColor(gridColor3),
 		GridColor4:   parseColor(gridColor4),
+		Limits:       walkerLimits(),
 	}
+}
 
-	return generateCard(ns, printing, "netwalker", "mangofeet")
+// walkerLimits are the --walker-* limits of netwalker and phungus
+func walkerLimits() art.WalkerLimits {
+	return art.WalkerLimits{
+		MaxSteps:    walkerMaxSteps,
+		StallWindow: walkerStallSteps,
+		// a design unit, 1/1200"
+		StallDistance: 1,
+		StepBudget:    walkerStepBudget,
+		TimeBudget:    walkerTimeBudget,
+	}
 }
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mangofeet/netrunner-alt-gen/art"
+	"github.com/mangofeet/netrunner-alt-gen/internal/metadata"
+	"github.com/mangofeet/nrdb-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// metadataKey is the PNG keyword, SVG metadata ID and PDF keyword
+// prefix the parameters are stored under
+const metadataKey = "netrunner-alt-gen"
+
+var (
+	// the command being run and its arguments, set before every run
+	activeCmd   *cobra.Command
+	commandArgs []string
+
+	// flags printed on the card back
+	cardBackParams []string
+)
+
+// cardSelectionFlags pick cards or where files go, they don't change
+// how a card looks so they aren't recorded
+var cardSelectionFlags = []string{
+	"help", "config", "card-db", "source", "card-file", "csv-columns",
+	"query", "pick", "set", "types", "factions", "pnp", "start-row",
+	"cache-dir", "cache-ttl", "offline", "no-cache", "output", "count", "render",
+}
+
+// outputFlags change the files written but not the card, they're left
+// off the card back
+var outputFlags = []string{
+	"make-back", "format", "dpi", "scale-factor", "crop",
+	"preview-rounded-corners", "keep-full-bleed", "layers", "filename-template",
+	"draw-margin-lines", "paper", "landscape", "columns", "rows", "gutter",
+	"cut-marks", "include-bleed", "duplex", "registration-offset", "ink-saver",
+	"animate", "animate-every", "animate-fps", "animate-width", "jobs", "max-memory",
+	"thumb-width",
+}
+
+// renderRecord is everything needed to render a card again, it's
+// stored as JSON in the output file
+type renderRecord struct {
+	Tool          string            `json:"tool"`
+	Version       string            `json:"version"`
+	Command       string            `json:"command"`
+	Args          []string          `json:"args,omitempty"`
+	Algorithm     string            `json:"algorithm"`
+	Designer      string            `json:"designer,omitempty"`
+	Frame         string            `json:"frame"`
+	PrintingID    string            `json:"printing_id"`
+	Seed          map[string]string `json:"seed"`
+	EffectiveSeed string            `json:"effective_seed,omitempty"`
+	Variant       int               `json:"variant,omitempty"`
+	Colors        map[string]string `json:"colors"`
+	Flags         map[string]string `json:"flags"`
+	CardBack      []string          `json:"card_back,omitempty"`
+	Printing      *nrdb.Printing    `json:"printing"`
+}
+
+func newRenderRecord(r cardRender) renderRecord {
+	card := r.card
+	rec := renderRecord{
+		Tool:       "netrunner-alt-gen",
+		Version:    version,
+		Algorithm:  r.algorithm,
+		Designer:   r.designer,
+		Frame:      r.frame,
+		PrintingID: card.ID,
+		Seed: map[string]string{
+			"title":   card.Attributes.Title,
+			"text":    card.Attributes.Text,
+			"type":    card.Attributes.CardTypeID,
+			"faction": card.Attributes.FactionID,
+			"flavor":  card.Attributes.Flavor,
+		},
+		EffectiveSeed: r.seed(),
+		Variant:       r.variant,
+		Colors:        map[string]string{},
+		Flags:         map[string]string{},
+		CardBack:      cardBackParams,
+		Printing:      card,
+	}
+
+	if activeCmd == nil {
+		return rec
+	}
+
+	rec.Command = activeCmd.Name()
+	rec.Args = commandArgs
+
+	visitFlags(activeCmd, func(flag *pflag.Flag) {
+		if slices.Contains(cardSelectionFlags, flag.Name) {
+			return
+		}
+		rec.Flags[flag.Name] = flagValue(flag)
+
+		if strings.Contains(flag.Name, "color") {
+			if c := parseColorInstruction(flag.Value.String(), card); c != nil {
+				rec.Colors[flag.Name] = colorHex(*c)
+			}
+		}
+	})
+
+	if _, ok := rec.Colors["base-color"]; !ok {
+		rec.Colors["base-color"] = colorHex(art.GetFactionBaseColor(card.Attributes.FactionID))
+	}
+
+	return rec
+}
+
+func (rec renderRecord) encode() (string, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (rec renderRecord) software() string {
+	return fmt.Sprintf("%s %s", rec.Tool, rec.Version)
+}
+
+func readRenderRecord(path string) (renderRecord, error) {
+	var rec renderRecord
+
+	data, err := metadata.Read(path, metadataKey)
+	if err != nil {
+		return rec, err
+	}
+
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return rec, fmt.Errorf("%s: invalid parameters: %w", path, err)
+	}
+	if rec.Printing == nil || rec.Printing.Attributes == nil {
+		return rec, fmt.Errorf("%s: parameters are missing the card", path)
+	}
+
+	return rec, nil
+}
+
+// visitFlags calls fn for every flag of the command, including the
+// ones inherited from the root command, in name order
+func visitFlags(cmd *cobra.Command, fn func(flag *pflag.Flag)) {
+	seen := map[string]bool{}
+	var flags []*pflag.Flag
+	add := func(flag *pflag.Flag) {
+		if !seen[flag.Name] {
+			seen[flag.Name] = true
+			flags = append(flags, flag)
+		}
+	}
+	cmd.LocalFlags().VisitAll(add)
+	cmd.InheritedFlags().VisitAll(add)
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	for _, flag := range flags {
+		fn(flag)
+	}
+}
+
+// lookupFlag finds a flag of the command or one it inherits
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if flag := cmd.LocalFlags().Lookup(name); flag != nil {
+		return flag
+	}
+	return cmd.InheritedFlags().Lookup(name)
+}
+
+// flagValue is the flag's value in a form Set accepts back
+func flagValue(flag *pflag.Flag) string {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		return strings.Join(slice.GetSlice(), ",")
+	}
+	return flag.Value.String()
+}
+
+// changedFlags lists the flags given on the command line that change
+// how the card looks, as they would be typed
+func changedFlags(cmd *cobra.Command) []string {
+	var params []string
+	visitFlags(cmd, func(flag *pflag.Flag) {
+		if !flag.Changed || slices.Contains(cardSelectionFlags, flag.Name) || slices.Contains(outputFlags, flag.Name) {
+			return
+		}
+
+		if flag.Value.Type() == "bool" && flag.Value.String() == "true" {
+			params = append(params, "--"+flag.Name)
+			return
+		}
+
+		value := flagValue(flag)
+		if strings.Contains(value, " ") || value == "" {
+			if strings.Contains(value, `"`) {
+				value = "'" + value + "'"
+			} else {
+				value = `"` + value + `"`
+			}
+		}
+		params = append(params, fmt.Sprintf("--%s %s", flag.Name, value))
+	})
+	return params
+}
+
+func colorHex(c color.RGBA) string {
+	if c.A == 0xff {
+		return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
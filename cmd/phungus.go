@@ -5,13 +5,15 @@ import (
 	"os"
 	"strings"
 
+	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/art/phungus"
+	"github.com/mangofeet/nrdb-go"
 	"github.com/spf13/cobra"
 )
 
 var phungusCmd = &cobra.Command{
 	Use:   "phungus [card name or printing ID]",
-	Args:  cobra.MinimumNArgs(1),
+	Args:  cardArgs(1),
 	Short: `Generate a card using the "phungus" algorithm`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -26,18 +28,18 @@ var phungusCmd = &cobra.Command{
 }
 
 func generateCardPhungus(cardName string) error {
-	printing, err := getCardData(cardName)
-	if err != nil {
-		return err
-	}
-	log.Printf("generating %s", printing.Attributes.StrippedTitle)
+	return forEachCard(cardName, "phungus", func(printing *nrdb.Printing) error {
+		return generateCard(phungusDrawer(), printing, "phungus", "mangofeet")
+	})
+}
 
+func phungusDrawer() art.Drawer {
 	var nGridP *float64
 	if gridPercent >= 0 {
 		nGridP = &gridPercent
 	}
 
-	ns := phungus.Entangler{
+	return phungus.Entangler{
 		MinWalkers:   walkersMin,
 		MaxWalkers:   walkersMax,
 		GridPercent:  nGridP,
@@ -55,7 +57,6 @@ func generateCardPhungus(cardName string) error {
 		RingColor2:   parseColor(altColor2),
 		RingColor3:   parseColor(altColor3),
 		RingColor4:   parseColor(altColor4),
+		Limits:       walkerLimits(),
 	}
-
-	return generateCard(ns, printing, "phungus", "mangofeet")
 }
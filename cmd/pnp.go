@@ -1,24 +1,29 @@
 package cmd
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
+	"image"
 	"log"
 	"os"
-	"strconv"
-	"strings"
+	"slices"
 
+	"github.com/mangofeet/netrunner-alt-gen/internal/source"
 	"github.com/mangofeet/nrdb-go"
 	"github.com/spf13/cobra"
 	"github.com/tdewolff/canvas"
 	"github.com/tdewolff/canvas/renderers/pdf"
-	"github.com/tdewolff/canvas/renderers/rasterizer"
 )
 
 var pnpCmd = &cobra.Command{
 	Use:   "pnp [CSV file]",
 	Args:  cobra.MinimumNArgs(1),
 	Short: "Generate a print & play file containing cards from a CSV",
+	Long: `Generate a print & play file containing cards from a CSV.
+
+Every card is drawn with --algorithm, or with the algorithm named in an
+"algorithm" column of its row. --ink-saver prints black and white
+frames without art instead.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := generatePnPFile(args[0]); err != nil {
 			log.Println("error:", err)
@@ -28,24 +33,69 @@ var pnpCmd = &cobra.Command{
 }
 
 func generatePnPFile(csvPath string) error {
-	const (
-		PAGE_WIDTH_MM  = 210
-		PAGE_HEIGHT_MM = 297
-		CARD_WIDTH_MM  = 60.0
-	)
 
 	// Load CSV file
-	csvFile, err := os.Open(csvPath)
+	csvOptions, err := getCSVOptions()
+	if err != nil {
+		return err
+	}
+
+	csvSource, rowErrs, err := source.LoadCSV(csvPath, csvOptions)
+	if err != nil {
+		return err
+	}
+
+	cards, err := csvSource.All()
 	if err != nil {
 		return err
 	}
-	defer csvFile.Close()
 
-	csvReader := csv.NewReader(csvFile)
-	records, err := csvReader.ReadAll()
+	skipped := map[int]bool{}
+	for _, err := range rowErrs {
+		log.Println("skipping:", err)
+		var csvErr source.CSVError
+		if errors.As(err, &csvErr) {
+			skipped[csvErr.Row] = true
+		}
+	}
+	log.Printf("loaded %d cards, skipped %d rows", len(cards), len(skipped))
+
+	if len(cards) == 0 {
+		return fmt.Errorf("no cards to print in %s", csvPath)
+	}
+
+	algorithms, err := source.LoadCSVColumn(csvPath, csvOptions, "algorithm")
 	if err != nil {
 		return err
 	}
+	if !inkSaver {
+		for id, name := range algorithms {
+			if _, err := getAlgorithm(name); err != nil {
+				return fmt.Errorf("%s: card %s: %w", csvPath, id, err)
+			}
+		}
+	}
+
+	return writePnPFile(cards, algorithms)
+}
+
+// writePnPFile lays the cards out on print & play pages following the
+// sheet flags, a card listed more than once is printed more than once
+// but only drawn once.
+// Cards are drawn with --algorithm unless cardAlgorithms names another
+// one for their printing ID.
+func writePnPFile(cards []*nrdb.Printing, cardAlgorithms map[string]string) error {
+
+	sheet, err := newSheetLayout()
+	if err != nil {
+		return err
+	}
+
+	if !inkSaver {
+		if _, err := getAlgorithm(algorithmName); err != nil {
+			return err
+		}
+	}
 
 	// Create output directory
 	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
@@ -54,76 +104,109 @@ func generatePnPFile(csvPath string) error {
 
 	// Open PDF file
 	pdfFilePath := fmt.Sprintf("%s/pnp.pdf", outputDir)
-	log.Printf("Generating print & play file at %s", pdfFilePath)
+	log.Printf("Generating print & play file at %s, %s", pdfFilePath, sheet)
 	pdfFile, err := os.Create(pdfFilePath)
 	if err != nil {
 		return err
 	}
 	defer pdfFile.Close()
 
-	// Instantiate variables
-	p := pdf.New(pdfFile, PAGE_WIDTH_MM, PAGE_HEIGHT_MM, nil)
-	pdfCanvas := canvas.New(PAGE_WIDTH_MM, PAGE_HEIGHT_MM)
-	pdfContext := canvas.NewContext(pdfCanvas)
-	pageMarginX := (PAGE_WIDTH_MM - (CARD_WIDTH_MM * 3)) / 2
-	pageMarginY := -1.0
+	if inkSaver {
+		useInkSaverColors()
+	}
 
-	// Override colors to black & white
-	baseColor = "ffffff"
-	frameColorBackground = "ffffff"
-	frameColorFactionBG = "ffffff"
-	frameColorStrengthBG = "ffffff"
-	frameColorInfluenceBG = "ffffff"
-	frameColorInfluenceLimitBG = "ffffff"
-	frameColorMinDeckBG = "ffffff"
-	frameColorBorder = "000000"
-	frameColorText = "000000"
+	withBacks := sheet.duplex != "none"
 
-	cardID := startRow
-	for i, record := range records[startRow-1:] {
-		card := buildCard(record, cardID)
+	jobs, err := batchJobs(sheet.perPage())
+	if err != nil {
+		return err
+	}
 
-		// Generate card image
-		cnv, err := generateCardCanvas(emptyDrawer{}, card, "", "")
-		if err != nil {
-			return err
+	// copies of a card share their art, so each printing is drawn
+	// once and kept until its last copy is placed
+	type pnpImages struct {
+		front, back image.Image
+	}
+	rendered := map[string]pnpImages{}
+	copiesLeft := map[string]int{}
+	for _, card := range cards {
+		copiesLeft[card.ID]++
+	}
+
+	p := pdf.New(pdfFile, sheet.pageW, sheet.pageH, nil)
+
+	for start := 0; start < len(cards); start += sheet.perPage() {
+		page := cards[start:min(start+sheet.perPage(), len(cards))]
+
+		if start > 0 {
+			p.NewPage(sheet.pageW, sheet.pageH)
 		}
 
-		// Calculate card dimensions
-		cardImg := rasterizer.Draw(cnv, canvas.DPMM(1), canvas.DefaultColorSpace)
-		imgDPMM := float64(cardImg.Bounds().Max.X) / CARD_WIDTH_MM
-		imgWidth := float64(cardImg.Bounds().Max.X) / imgDPMM
-		imgHeight := float64(cardImg.Bounds().Max.Y) / imgDPMM
-		if pageMarginY == -1 {
-			pageMarginY = (PAGE_HEIGHT_MM - (imgHeight * 3)) / 2
+		frontCnv := canvas.New(sheet.pageW, sheet.pageH)
+		frontCtx := canvas.NewContext(frontCnv)
+		backCnv := canvas.New(sheet.pageW, sheet.pageH)
+		backCtx := canvas.NewContext(backCnv)
+
+		// the new cards of a page are rendered side by side, then
+		// placed in order
+		var todo []*nrdb.Printing
+		for _, card := range page {
+			_, done := rendered[card.ID]
+			if !done && !slices.ContainsFunc(todo, func(other *nrdb.Printing) bool { return other.ID == card.ID }) {
+				todo = append(todo, card)
+			}
 		}
 
-		// Draw image
-		imageIndex := i % 9
-		pageX := pageMarginX + (float64(i%3) * imgWidth)
-		pageY := PAGE_HEIGHT_MM - (float64((imageIndex/3)+1) * imgHeight) - pageMarginY
-		pdfContext.DrawImage(pageX, pageY, cardImg, canvas.DPMM(imgDPMM))
+		images := make([]pnpImages, len(todo))
+		errs := make([]error, len(todo))
+		parallel(len(todo), jobs, func(i int) {
+			front, back, err := renderPnPCard(todo[i], cardAlgorithms, withBacks)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", todo[i].Attributes.Title, err)
+				return
+			}
+			images[i].front = sheet.cardImage(front)
+			if withBacks {
+				images[i].back = sheet.cardImage(back)
+			}
+		})
+		if interrupted.Err() != nil {
+			return errInterrupted
+		}
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+		for i, card := range todo {
+			rendered[card.ID] = images[i]
+		}
+
+		for i, card := range page {
+			images := rendered[card.ID]
 
-		cardID += 1
+			x, y := sheet.slot(i)
+			sheet.drawCard(frontCtx, x, y, images.front)
 
-		// Quit if we reached the last record
-		if i == len(records)-startRow-1 {
-			break
+			if withBacks {
+				x, y := sheet.backSlot(i)
+				sheet.drawCard(backCtx, x, y, images.back)
+			}
+
+			copiesLeft[card.ID]--
+			if copiesLeft[card.ID] == 0 {
+				delete(rendered, card.ID)
+			}
 		}
 
-		// Render the page and create a new one
-		if i%9 == 8 {
-			pdfCanvas.RenderTo(p)
+		sheet.drawCutMarks(frontCtx)
+		frontCnv.RenderTo(p)
 
-			p.NewPage(PAGE_WIDTH_MM, PAGE_HEIGHT_MM)
-			pdfCanvas = canvas.New(PAGE_WIDTH_MM, PAGE_HEIGHT_MM)
-			pdfContext = canvas.NewContext(pdfCanvas)
+		if withBacks {
+			// the backs go on the next page, behind the fronts
+			p.NewPage(sheet.pageW, sheet.pageH)
+			backCnv.RenderTo(p)
 		}
 	}
 
-	// Render the last page
-	pdfCanvas.RenderTo(p)
-
 	if err := p.Close(); err != nil {
 		return err
 	}
@@ -131,89 +214,37 @@ func generatePnPFile(csvPath string) error {
 	return nil
 }
 
-func buildCard(record []string, cardID int) *nrdb.Printing {
-	// Instantiate Printing
-	card := &nrdb.Printing{}
-	card.Attributes = &nrdb.PrintingAttributes{}
-	card.Attributes.CardAbilities = &nrdb.CardAbilities{}
-
-	titleStripper := strings.NewReplacer(" ", "", ".", "", ",", "", "-", "", "!", "", "◆", "")
-
-	// Split summary into sections for ease of use
-	summary_sections := strings.Split(record[5], "====")
-	lower_sections := summary_sections[1]
-	summary_sections = []string{summary_sections[0]}
-	summary_sections = append(summary_sections, strings.Split(lower_sections, "----")...)
-	for i, section := range summary_sections {
-		summary_sections[i] = strings.Trim(section, "\n")
-	}
-
-	// Set ID, faction, name, and type
-	card.ID = strconv.Itoa(cardID)
-	card.Attributes.PositionInSet = cardID
-	if record[1] == "Weyland" {
-		card.Attributes.FactionID = "weyland_consortium"
-	} else {
-		card.Attributes.FactionID = strings.ReplaceAll(strings.ToLower(record[1]), "-", "_")
-	}
-	card.Attributes.Title = strings.ReplaceAll(record[3], "\n", "")
-	card.Attributes.StrippedTitle = titleStripper.Replace(record[3])
-	card.Attributes.IsUnique = strings.Contains(summary_sections[0], "◆")
-	if record[4] == "Runner-ID" {
-		card.Attributes.CardTypeID = "runner_identity"
-		card.Attributes.CardAbilities.MUProvided = nil
-	} else if record[4] == "Corp-ID" {
-		card.Attributes.CardTypeID = "corp_identity"
-	} else {
-		card.Attributes.CardTypeID = strings.ToLower(record[4])
-	}
-
-	// Set subtypes
-	type_line := strings.Split(summary_sections[1], " ")
-	subtypes := []string{}
-	for _, token := range type_line {
-		if !strings.HasSuffix(token, ":") && token != "-" {
-			subtypes = append(subtypes, token)
-		}
+// renderPnPCard draws a card, and its back with withBack, with the
+// algorithm chosen for it
+func renderPnPCard(card *nrdb.Printing, cardAlgorithms map[string]string, withBack bool) (*canvas.Canvas, *canvas.Canvas, error) {
+
+	if inkSaver {
+		return generateCardCanvas(emptyDrawer{}, card, "", "", withBack)
 	}
-	card.Attributes.DisplaySubtypes = new(string)
-	*card.Attributes.DisplaySubtypes = strings.Join(subtypes, "-")
-
-	// Set numeric values
-	cost_line := strings.Split(summary_sections[2], ", ")
-	for _, cost := range cost_line {
-		tokens := strings.Split(cost, ": ")
-		val, _ := strconv.Atoi(tokens[1])
-		switch tokens[0] {
-		case "Advancements":
-			card.Attributes.AdvancementRequirement = &tokens[1]
-		case "Cost":
-			card.Attributes.Cost = &tokens[1]
-		case "Deck":
-			card.Attributes.MinimumDeckSize = &val
-		case "Influence":
-			card.Attributes.InfluenceCost = &val
-			card.Attributes.InfluenceLimit = &val
-		case "Memory":
-			card.Attributes.MemoryCost = &val
-		case "Points":
-			card.Attributes.AgendaPoints = &val
-		case "Strength":
-			card.Attributes.Strength = &val
-		case "Trash":
-			card.Attributes.MinimumDeckSize = &val
-		}
+
+	name := algorithmName
+	if cardAlgorithm, ok := cardAlgorithms[card.ID]; ok {
+		name = cardAlgorithm
 	}
 
-	// Set text
-	card.Attributes.Text = strings.Trim(summary_sections[3], "\n")
-	card.Attributes.Text = strings.ReplaceAll(card.Attributes.Text, "{mu}", "[mu]")
-	card.Attributes.Text = strings.ReplaceAll(card.Attributes.Text, "{c}", "[credit]")
-	card.Attributes.Text = strings.ReplaceAll(card.Attributes.Text, "{recurring}", "[recurring-credit]")
-	card.Attributes.Text = strings.ReplaceAll(card.Attributes.Text, "{click}", "[click]")
-	card.Attributes.Text = strings.ReplaceAll(card.Attributes.Text, "{sub}", "[subroutine]")
-	card.Attributes.Text = strings.ReplaceAll(card.Attributes.Text, "{trash}", "[trash]")
-	card.Attributes.Text = strings.ReplaceAll(card.Attributes.Text, "{interrupt}", "[interrupt]")
+	alg, err := getAlgorithm(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("drawing %s with %s", card.Attributes.Title, name)
+	return generateCardCanvas(alg.drawer(), card, name, alg.designer, withBack)
+}
 
-	return card
+// useInkSaverColors overrides the colors with black on white
+func useInkSaverColors() {
+	baseColor = "ffffff"
+	frameColorBackground = "ffffff"
+	frameColorFactionBG = "ffffff"
+	frameColorStrengthBG = "ffffff"
+	frameColorInfluenceBG = "ffffff"
+	frameColorInfluenceLimitBG = "ffffff"
+	frameColorMinDeckBG = "ffffff"
+	frameColorBorder = "000000"
+	frameColorText = "000000"
 }
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/mangofeet/netrunner-alt-gen/art"
+	"github.com/tdewolff/canvas"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// interrupted is done after the first Ctrl-C, which stops the art of
+// every card being drawn. A second Ctrl-C exits straight away.
+var interrupted = context.Background()
+
+// catchInterrupt sets up interrupted, stop undoes it
+func catchInterrupt() (stop func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	interrupted = ctx
+	return stop
+}
+
+// drawArt draws the art of a card from its seed, giving up on Ctrl-C
+// or once it takes longer than --timeout
+func drawArt(drawer art.Drawer, ctx *canvas.Context, r cardRender) error {
+	run := interrupted
+	if artTimeout > 0 {
+		var cancel context.CancelFunc
+		run, cancel = context.WithTimeout(run, artTimeout)
+		defer cancel()
+	}
+
+	seed := r.seed()
+	params := art.Params{Card: r.card, Seed: &seed, Log: r.logger()}
+	if label := r.seedLabel(); label != "" {
+		r.titledLogger().Printf("seed %s", label)
+	}
+	if logProgress {
+		params.Progress = &progressLog{log: r.titledLogger()}
+	}
+
+	err := art.Upgrade(drawer).DrawV2(run, ctx, params)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("the art took longer than --timeout %s", artTimeout)
+	}
+	if errors.Is(err, context.Canceled) {
+		return errInterrupted
+	}
+	return err
+}
+
+// logCardNames is set while parallel renders cards side by side, so
+// the lines of each card say which one they belong to
+var logCardNames bool
+
+// logger is where the lines of a card are logged, with the card in
+// front of them when other cards are being rendered at the same time
+func (r cardRender) logger() *log.Logger {
+	if !logCardNames {
+		return log.Default()
+	}
+	return r.titledLogger()
+}
+
+// titledLogger puts the title of the card, and its variant when it
+// has one, in front of every line
+func (r cardRender) titledLogger() *log.Logger {
+	name := r.card.Attributes.StrippedTitle
+	if r.variant != 0 {
+		name += fmt.Sprintf(" variant %d", r.variant)
+	}
+	return log.New(log.Writer(), name+": ", log.Flags()|log.Lmsgprefix)
+}
+
+// progressLog logs the progress of a card for --progress, walkers
+// every 10%
+type progressLog struct {
+	log   *log.Logger
+	tenth int
+}
+
+func (p *progressLog) Stage(name string) {
+	p.log.Println(name)
+	p.tenth = 0
+}
+
+func (p *progressLog) Walkers(done, total int) {
+	tenth := done * 10 / total
+	if tenth == p.tenth {
+		return
+	}
+	p.tenth = tenth
+	p.log.Printf("%d of %d walkers", done, total)
+}
+
+func (p *progressLog) Rings(done, total int) {
+	p.log.Printf("%d of %d sets of rings", done, total)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mangofeet/netrunner-alt-gen/art"
+	"github.com/spf13/cobra"
+	"github.com/tdewolff/canvas"
+)
+
+var reproduceCmd = &cobra.Command{
+	Use:   "reproduce [generated PNG, SVG or PDF file]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Render a card again from the parameters stored in a file it generated",
+	Long: `Render a card again from the parameters stored in a file it generated.
+
+Every PNG, SVG and PDF file records the card, command, flags and
+resolved colors it was made with. reproduce reads them back and renders
+the same image into the output directory. Output flags given to
+reproduce, such as --dpi or --format, replace the recorded ones.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := reproduceCard(cmd, args[0]); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func reproduceCard(cmd *cobra.Command, path string) error {
+
+	rec, err := readRenderRecord(path)
+	if err != nil {
+		return err
+	}
+
+	if rec.Version != version {
+		log.Printf("warning: %s was made with %s, this is version %s, the art may differ", path, rec.software(), version)
+	}
+
+	origCmd, _, err := rootCmd.Find([]string{rec.Command})
+	if err != nil || origCmd == rootCmd {
+		return fmt.Errorf(`%s: unknown command "%s"`, path, rec.Command)
+	}
+
+	if err := restoreFlags(cmd, origCmd, rec); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := applyOutputFlags(); err != nil {
+		return err
+	}
+
+	frame = rec.Frame
+	activeCmd, commandArgs = origCmd, rec.Args
+	cardBackParams = rec.CardBack
+
+	drawer, err := reproduceDrawer(rec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	log.Printf("reproducing %s with %s", rec.Printing.Attributes.Title, rec.Command)
+
+	r := reproduceRender(rec)
+
+	cnv := canvas.New(canvasWidth, canvasHeight)
+	ctx := canvas.NewContext(cnv)
+
+	if err := drawArt(drawer, ctx, r); err != nil {
+		return err
+	}
+
+	return output(cnv, ctx, r)
+}
+
+// restoreFlags sets the recorded flags on the command the record was
+// made with, except the ones given to reproduce
+func restoreFlags(cmd, origCmd *cobra.Command, rec renderRecord) error {
+	for name, value := range rec.Flags {
+		// flags given to reproduce win, the config file doesn't
+		if flag := lookupFlag(cmd, name); flag != nil && flag.Changed && !configFlags[name] {
+			continue
+		}
+
+		flag := lookupFlag(origCmd, name)
+		if flag == nil {
+			log.Printf(`warning: ignoring unknown flag "%s"`, name)
+			continue
+		}
+		// through the flag set, so the restored flags count as changed
+		// like typed ones
+		if err := origCmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf(`invalid value for "%s": %w`, name, err)
+		}
+	}
+	return nil
+}
+
+// reproduceRender is the recorded card render
+func reproduceRender(rec renderRecord) cardRender {
+	r := newCardRender(rec.Printing, rec.Algorithm, rec.Designer)
+	// explore renders variants without --variant
+	r.variant = rec.Variant
+	// the seed as it was, even if the card data or the way seeds
+	// are made from it changed since
+	r.effectiveSeed = rec.EffectiveSeed
+	return r
+}
+
+// reproduceDrawer builds the art drawer the record was made with from
+// the restored flags
+func reproduceDrawer(rec renderRecord) (art.Drawer, error) {
+
+	name := rec.Command
+	if _, ok := algorithms[name]; !ok && rec.Flags["algorithm"] != "" {
+		// set and deck pick the algorithm with a flag
+		name = rec.Flags["algorithm"]
+	}
+
+	switch name {
+	case "tracker":
+		return trackerDrawer(), nil
+	case "image":
+		if len(rec.Args) == 0 {
+			return nil, fmt.Errorf("no image file recorded")
+		}
+		return imageDrawer{filename: rec.Args[0]}, nil
+	}
+
+	alg, err := getAlgorithm(name)
+	if err != nil {
+		return nil, err
+	}
+	return alg.drawer(), nil
+}
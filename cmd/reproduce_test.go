@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRestoreFlags(t *testing.T) {
+
+	origFlags := configFlags
+	t.Cleanup(func() { configFlags = origFlags })
+
+	for _, fromConfig := range []bool{false, true} {
+		root := &cobra.Command{Use: "root"}
+		root.PersistentFlags().String("color-bg", "", "")
+		gen := &cobra.Command{Use: "gen"}
+		gen.Flags().Int("walkers", 0, "")
+		repro := &cobra.Command{Use: "reproduce"}
+		root.AddCommand(gen, repro)
+
+		if err := repro.ParseFlags([]string{"--color-bg", "#ffffff"}); err != nil {
+			t.Fatal(err)
+		}
+		configFlags = map[string]bool{"color-bg": fromConfig}
+
+		rec := renderRecord{Flags: map[string]string{
+			"color-bg": "#000000",
+			"walkers":  "3000",
+			"removed":  "1",
+		}}
+		if err := restoreFlags(repro, gen, rec); err != nil {
+			t.Fatal(err)
+		}
+
+		walkers := lookupFlag(gen, "walkers")
+		if walkers.Value.String() != "3000" || !walkers.Changed {
+			t.Errorf("walkers is %s, changed %t", walkers.Value, walkers.Changed)
+		}
+
+		want := "#ffffff"
+		if fromConfig {
+			want = "#000000"
+		}
+		if got := lookupFlag(gen, "color-bg").Value.String(); got != want {
+			t.Errorf("color-bg from the config %t: got %s, want %s", fromConfig, got, want)
+		}
+	}
+
+	gen := &cobra.Command{Use: "gen"}
+	gen.Flags().Int("walkers", 0, "")
+	err := restoreFlags(&cobra.Command{}, gen, renderRecord{Flags: map[string]string{"walkers": "many"}})
+	if err == nil {
+		t.Error("expected an invalid value to fail")
+	}
+}
+
+func TestReproduceRenderKeepsSeed(t *testing.T) {
+
+	withSeedFlags(t, "", nil)
+
+	r := newCardRender(seedCard(), "netwalker", "")
+	r.variant = 2
+	rec := newRenderRecord(r)
+
+	// the card changed since, the recorded seed still draws the same
+	rec.Printing = seedCard()
+	rec.Printing.Attributes.Text = "Gain 8[credit]."
+
+	got := reproduceRender(rec)
+	if got.seed() != r.seed() {
+		t.Errorf("got seed %q, want the recorded %q", got.seed(), r.seed())
+	}
+	if got.seedLabel() != r.seedLabel() {
+		t.Errorf("got label %q, want %q", got.seedLabel(), r.seedLabel())
+	}
+	if got.variant != 2 {
+		t.Errorf("got variant %d, want 2", got.variant)
+	}
+
+	// records from before the effective seed work from the card
+	rec.EffectiveSeed = ""
+	if got := reproduceRender(rec); got.seed() == r.seed() {
+		t.Error("expected the seed of the changed card")
+	}
+}
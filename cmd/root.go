@@ -4,51 +4,29 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/mangofeet/netrunner-alt-gen/frame/basic"
+	"github.com/mangofeet/netrunner-alt-gen/art"
+	"github.com/mangofeet/netrunner-alt-gen/layout"
 	"github.com/spf13/cobra"
 )
 
-// old values
-
-// const canvasWidth = 3288.0
-// const canvasHeight = 4488.0
-// const cardWidth = 3064.0
-// const cardHeight = 4212.0
-
-// based on NSG pdf sizes
-
-// const canvasWidth = 3199.0
-// const canvasHeight = 4432.0
-// const cardWidth = 2975.0
-// const cardHeight = 4156.0
-
-// based on MPC template
-
-// const canvasWidth = 3264.0
-// const canvasHeight = 4450.0
-// const cardWidth = 2976.0
-// const cardHeight = 4152.0
-// const safeWidth = 2736.0
-// const safeHeight = 3924.0
-
-// real card MM, doesn't work currently, need higehr res for
-// generation
-
-// const canvasWidth = 69.35
-// const canvasHeight = 94.35
-// const cardWidth = 63.0
-// const cardHeight = 88.0
-
 var (
-	canvasWidth, canvasHeight, cardWidth, cardHeight, safeWidth, safeHeight float64 = 3264.0, 4450.0, 2976.0, 4152.0, 2736.0, 3924.0
+	// card sizes in design units, see the layout package
+	canvasWidth, canvasHeight = layout.CanvasWidth, layout.CanvasHeight
+	cardWidth, cardHeight     = layout.CardWidth, layout.CardHeight
+	safeWidth, safeHeight     = layout.SafeWidth, layout.SafeHeight
 
 	drawMarginLines, makeBack                                           bool
-	outputDir                                                           string
+	outputDir, outputFormat, cropMode, layerFormat                      string
+	fileNameTemplate                                                    string
+	previewRoundedCorners, keepFullBleed                                bool
 	baseColor, altColor1, altColor2, altColor3, altColor4, overlayColor string
 	skipFlavor                                                          bool
 	flavorText, flavorAttribution                                       string
-	textBoxFactor, scaleFactor                                          float64
+	textBoxFactor, scaleFactor, dpi                                     float64
 
 	frame, frameColorBackground, frameColorBorder, frameColorText,
 	frameColorTextStrength, frameColorInfluencePips,
@@ -61,12 +39,63 @@ var (
 	walkerColor1, walkerColor2, walkerColor3, walkerColor4 string
 	gridColor1, gridColor2, gridColor3, gridColor4         string
 	gridPercent                                            float64
+	walkerMaxSteps, walkerStallSteps, walkerStepBudget     int
+	walkerTimeBudget                                       time.Duration
 
 	// image
 	designer string
 
+	// batches
+	algorithmName               string
+	filterTypes, filterFactions []string
+	pnpDeck                     bool
+
 	// pnp
-	startRow int
+	startRow   int
+	csvColumns string
+
+	// print sheets
+	paperSize, cutMarks, duplex string
+	sheetColumns, sheetRows     int
+	sheetGutter                 float64
+	sheetLandscape, sheetBleed  bool
+	inkSaver                    bool
+	registrationOffset          []float64
+
+	// gallery
+	galleryColumns, thumbWidth, galleryPerSheet int
+
+	// explore
+	exploreCount, exploreColumns int
+	exploreRender                []int
+
+	// parallel batches
+	maxJobs   int
+	maxMemory string
+
+	// drawing
+	artTimeout  time.Duration
+	logProgress bool
+
+	// seeds
+	seedOverride string
+	seedGiven    bool
+	seedVariant  int
+	seedFields   []string
+
+	// animation
+	animateFormat              string
+	animateEvery, animateWidth int
+	animateFPS                 float64
+
+	// card data
+	configFile, cardDB, cardSourceSpec, cardFile, printingSet, cardQuery string
+	pick                                                                 int
+
+	// cache
+	cacheDir                        string
+	cacheTTL                        time.Duration
+	offline, noCache, cachePruneAll bool
 
 	// set by ldflags
 	version string = "local"
@@ -76,13 +105,69 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&drawMarginLines, "draw-margin-lines", "", false, `Draw bleed and "safe area" lines`)
 	rootCmd.PersistentFlags().BoolVarP(&makeBack, "make-back", "", false, `Also create a file for a card back. Uses "${frame}-back" as frame name.`)
 	rootCmd.PersistentFlags().StringVarP(&outputDir, "output", "o", "output", `Output directory name`)
+	rootCmd.PersistentFlags().StringVarP(&fileNameTemplate, "filename-template", "", defaultFileNameTemplate,
+		`Output file name without the extension, "/" makes subdirectories and a trailing "/" keeps the default name in them. Placeholders: {id}, {code}, {set}, {cycle}, {position}, {title}, {faction}, {type}, {side}, {algorithm}, {seed}, {variant}`)
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "", "png", `Output file format, one of png, jpg, tiff, svg or pdf. svg and pdf keep the frame as vectors over the rendered art`)
+	rootCmd.PersistentFlags().StringVarP(&cropMode, "crop", "", "none",
+		`Crop the output to part of the card
+  none, bleed   the whole card including 1/8" of bleed
+  trim          the cut line
+  safe          the safe area inside the cut line`)
+	rootCmd.PersistentFlags().BoolVarP(&previewRoundedCorners, "preview-rounded-corners", "", false, `Clear everything outside the rounded corners of the cut card, for previews of raster output`)
+	rootCmd.PersistentFlags().BoolVarP(&keepFullBleed, "keep-full-bleed", "", false, `When cropping, also write the full bleed file and put the cropped one in a "cropped" directory`)
+	rootCmd.PersistentFlags().StringVarP(&layerFormat, "layers", "", "none",
+		`Also write the art, frame and margin guides as separate layers with a JSON manifest
+  png   art.png, frame.png and guides.png in a directory named after the card
+  ora   a single OpenRaster file for GIMP or Krita`)
+	rootCmd.PersistentFlags().Lookup("layers").NoOptDefVal = "png"
+	rootCmd.PersistentFlags().IntVarP(&maxJobs, "jobs", "j", 1, `Cards of a batch to render at once, 0 for one per CPU. Fewer are rendered when they wouldn't fit in memory`)
+	rootCmd.PersistentFlags().StringVarP(&maxMemory, "max-memory", "", "", `Memory a batch may use, e.g. 4G, limits --jobs. Defaults to the memory available when the batch starts`)
+	rootCmd.PersistentFlags().DurationVarP(&artTimeout, "timeout", "", 0, `Give up on the art of a card that takes longer than this, e.g. 5m, 0 waits for it. Ctrl-C also stops the art, a second Ctrl-C exits`)
+	rootCmd.PersistentFlags().BoolVarP(&logProgress, "progress", "", false, `Log the stages of the art, walkers and rings as they're drawn`)
+	rootCmd.PersistentFlags().VarP(seedFlag{}, "seed", "", `Seed the art with this text instead of the card, the same seed always draws the same art`)
+	rootCmd.PersistentFlags().IntVarP(&seedVariant, "variant", "", 0, `Draw the Nth variant of the art, a different but repeatable seed. 0 is the usual art`)
+	rootCmd.PersistentFlags().StringSliceVarP(&seedFields, "seed-fields", "", nil,
+		fmt.Sprintf(`Card fields the art is seeded with, any of %s. Defaults to all of them, the title for tracker`, strings.Join(art.SeedFields, ", ")))
+	rootCmd.PersistentFlags().StringVarP(&animateFormat, "animate", "", "none",
+		`Also write an animation of the art being drawn, with the frame over every step, for the netwalker, netringer, phungus and tracker algorithms
+  gif      an animated GIF
+  apng     an animated PNG
+  frames   a directory of numbered PNG files`)
+	rootCmd.PersistentFlags().Lookup("animate").NoOptDefVal = "gif"
+	rootCmd.PersistentFlags().IntVarP(&animateEvery, "animate-every", "", 10000, `Walker steps between animation frames, rings always get a frame each`)
+	rootCmd.PersistentFlags().Float64VarP(&animateFPS, "animate-fps", "", 15, `Animation frames per second, the finished card is held for 2 seconds at the end`)
+	rootCmd.PersistentFlags().IntVarP(&animateWidth, "animate-width", "", 540, `Animation width in pixels, the height follows the card`)
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "", "", `Path to a JSON config file of default flag values, defaults to "netrunner-alt-gen/config.json" in the user config directory`)
+	rootCmd.PersistentFlags().StringVarP(&cardDB, "card-db", "", "", `Directory containing a local NRDB v3 JSON dump to load cards from, shorthand for --source dump:<dir>`)
+	rootCmd.PersistentFlags().StringVarP(&cardSourceSpec, "source", "", "nrdb",
+		`Where to look up cards, a comma separated list searched in order
+  nrdb          the live NRDB API
+  dump:<dir>    a local NRDB v3 JSON dump
+  csv:<file>    a print & play CSV
+  def:<file>    custom card definitions, see "validate"`)
+	rootCmd.PersistentFlags().StringVarP(&cardFile, "card-file", "", "", `Render a custom card from a YAML or JSON card definition instead of looking it up, see "validate" for the format`)
+	rootCmd.PersistentFlags().StringVarP(&cardQuery, "query", "q", "",
+		`Generate every card matching a search, e.g. "faction:jinteki type:ice strength>=4"
+Fields: faction (f), type (t), side (d), subtype (s), set (e), cycle (c),
+title (_), text (x), cost (o), strength (p), influence (n), memory (m),
+trash (h), points (v), advancement (g), link (l)
+Operators: ":" equals, "!" not, "<", "<=", ">", ">=" for numbers,
+"|" separates alternatives, e.g. f:anarch|criminal`)
+	rootCmd.PersistentFlags().IntVarP(&pick, "pick", "", 0, `Use the Nth result when a card name matches more than one card`)
+	rootCmd.PersistentFlags().StringVarP(&printingSet, "set", "", "", `Use the printing of the card from this card set ID instead of the latest printing`)
+	rootCmd.PersistentFlags().StringVarP(&cacheDir, "cache-dir", "", "", `Directory for cached NRDB responses, defaults to "netrunner-alt-gen/http" in the user cache directory`)
+	rootCmd.PersistentFlags().DurationVarP(&cacheTTL, "cache-ttl", "", time.Hour*24*7, `How long cached NRDB responses are used before fetching them again, 0 keeps them forever`)
+	rootCmd.PersistentFlags().BoolVarP(&offline, "offline", "", false, `Only use cached NRDB responses, failing when a request isn't cached`)
+	rootCmd.PersistentFlags().BoolVarP(&noCache, "no-cache", "", false, `Don't read or write the NRDB response cache`)
 
 	rootCmd.PersistentFlags().StringVarP(&flavorText, "flavor", "", "", `Flavor text to add to the generated card`)
 	rootCmd.PersistentFlags().StringVarP(&flavorAttribution, "flavor-attribution", "", "", `Flavor text attribution to add to the generated card, for "quotes"`)
 	rootCmd.PersistentFlags().BoolVarP(&skipFlavor, "skip-flavor", "", false, `Don't render default flavor text`)
 	rootCmd.PersistentFlags().StringVarP(&baseColor, "base-color", "c", "", `Alternate base color for the card, defaults to pre-defined faction colors`)
 	rootCmd.PersistentFlags().Float64VarP(&textBoxFactor, "text-box-height", "", 33.3, `Percentage of total card height taken up by the main text box`)
-	rootCmd.PersistentFlags().Float64VarP(&scaleFactor, "scale-factor", "", 1.0, `Scaling factor of entire image, defaults to 1.0 (1.0 = 1200DPI)`)
+	rootCmd.PersistentFlags().Float64VarP(&dpi, "dpi", "", layout.DesignDPI, `Resolution of raster output, e.g. 300, 600 or 1200. The card is the same at any resolution`)
+	rootCmd.PersistentFlags().Float64VarP(&scaleFactor, "scale-factor", "", 1.0, `Scaling factor of entire image (1.0 = 1200DPI)`)
+	rootCmd.PersistentFlags().MarkDeprecated("scale-factor", "use --dpi instead")
 
 	rootCmd.PersistentFlags().StringVarP(&frame, "frame", "f", "basic", `Frame to draw, use "none" to skip drawing a frame`)
 	rootCmd.PersistentFlags().StringVarP(&frameColorBackground, "frame-color-background", "", "1c1c1c99", `Background color for frame text boxes`)
@@ -130,7 +215,32 @@ If set to "faction", it will use the faction color regardless of the base color`
 
 	reflectionCmd.Flags().StringVarP(&colorBG, "color-bg", "", "", `Background color for the generated art, defaults to a darkened --base-color value`)
 
-	pnpCmd.Flags().IntVarP(&startRow, "start-row", "m", 2, `Row to start generating from, defaults to 1 (this assumes the CSV contains a header row)`)
+	algorithmFlags(setCmd)
+	batchFilterFlags(setCmd)
+
+	algorithmFlags(deckCmd)
+	deckCmd.Flags().BoolVarP(&pnpDeck, "pnp", "", false, `Write a print & play PDF with every copy of every card instead of one image per card`)
+	sheetFlags(deckCmd)
+
+	galleryCmd.Flags().IntVarP(&galleryColumns, "columns", "", 6, `Thumbnails across the contact sheet`)
+	galleryCmd.Flags().IntVarP(&thumbWidth, "thumb-width", "", 300, `Thumbnail width in pixels`)
+	galleryCmd.Flags().IntVarP(&galleryPerSheet, "per-sheet", "", 0, `Cards per contact sheet, more cards are split over numbered sheets, defaults to all of them on one`)
+
+	algorithmFlags(exploreCmd)
+	exploreCmd.Flags().IntVarP(&exploreCount, "count", "n", 12, `Variants to put on the grid, starting from --variant`)
+	exploreCmd.Flags().IntVarP(&exploreColumns, "columns", "", 4, `Variants across the grid`)
+	exploreCmd.Flags().IntVarP(&thumbWidth, "thumb-width", "", 300, `Width of each variant on the grid in pixels, they're rendered at this size`)
+	exploreCmd.Flags().IntSliceVarP(&exploreRender, "render", "r", nil, `Render these variant numbers of the grid at full resolution instead, e.g. "3,7"`)
+
+	cachePruneCmd.Flags().BoolVarP(&cachePruneAll, "all", "", false, `Remove every response, not just expired ones`)
+	cacheCmd.AddCommand(cacheInfoCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+
+	pnpCmd.Flags().IntVarP(&startRow, "start-row", "m", 0, `Row to start generating from, defaults to the first row after the header`)
+	algorithmFlags(pnpCmd)
+	sheetFlags(pnpCmd)
+	rootCmd.PersistentFlags().StringVarP(&csvColumns, "csv-columns", "", "", `YAML or JSON file mapping CSV headers to card fields, for spreadsheets with their own column names`)
 
 	rootCmd.AddCommand(netwalkerCmd)
 	rootCmd.AddCommand(emptyCmd)
@@ -141,11 +251,27 @@ If set to "faction", it will use the faction color regardless of the base color`
 	rootCmd.AddCommand(reflectionCmd)
 	rootCmd.AddCommand(trackerCmd)
 	rootCmd.AddCommand(pnpCmd)
+	rootCmd.AddCommand(setCmd)
+	rootCmd.AddCommand(deckCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(reproduceCmd)
+	rootCmd.AddCommand(galleryCmd)
+	rootCmd.AddCommand(exploreCmd)
+}
+
+// unusedShorthand returns shorthand unless the command already has a
+// flag using it, pnp had -m for --start-row before it drew art
+func unusedShorthand(cmd *cobra.Command, shorthand string) string {
+	if cmd.Flags().ShorthandLookup(shorthand) != nil {
+		return ""
+	}
+	return shorthand
 }
 
 func commonNetspaceFlags(cmd *cobra.Command) {
-	cmd.Flags().IntVarP(&walkersMin, "min-walkers", "m", 3000, `Minimum amount of walkers`)
-	cmd.Flags().IntVarP(&walkersMax, "max-walkers", "M", 10000, `Maximum amount of walkers`)
+	cmd.Flags().IntVarP(&walkersMin, "min-walkers", unusedShorthand(cmd, "m"), 3000, `Minimum amount of walkers`)
+	cmd.Flags().IntVarP(&walkersMax, "max-walkers", unusedShorthand(cmd, "M"), 10000, `Maximum amount of walkers`)
 	cmd.Flags().StringVarP(&colorBG, "color-bg", "", "", `Background color for the generated art, defaults to a darkened --base-color value`)
 	cmd.Flags().StringVarP(&walkerColor1, "walker-color-1", "", "", `Alternate walker color for the card, defaults to pre-defined faction color analogue +10 - +30`)
 	cmd.Flags().StringVarP(&walkerColor2, "walker-color-2", "", "", `Alternate walker color for the card, defaults to pre-defined faction color analogue -10 - -30`)
@@ -160,6 +286,15 @@ func commonNetspaceFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&gridColor4, "grid-color-4", "", "",
 		`Alternate grid color for the grid pattern on the card, defaults to --alt-color-4, will be randomly desaturated by algorithm`)
 	cmd.PersistentFlags().Float64VarP(&gridPercent, "grid-percent", "", -1, `Percentage of total walkers that will run on a grid`)
+	cmd.Flags().IntVarP(&walkerMaxSteps, "walker-max-steps", "", 1000000, `Most steps a walker takes before it's stopped, 0 for no limit`)
+	cmd.Flags().IntVarP(&walkerStallSteps, "walker-stall-steps", "", 1000, `Stop a walker that has moved less than 1/1200" in this many steps, 0 never stops stalled walkers`)
+	cmd.Flags().IntVarP(&walkerStepBudget, "walker-step-budget", "", 0, `Steps all the walkers of a card may take between them, the walkers left after that aren't drawn. 0 for no limit`)
+	cmd.Flags().DurationVarP(&walkerTimeBudget, "walker-time-budget", "", 0, `Time all the walkers of a card may take between them, e.g. 2m, the walkers left after that aren't drawn. 0 for no limit`)
+}
+
+func batchFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&filterTypes, "types", "", nil, `Only generate these card type IDs, e.g. "ice,program", "identity" matches both sides`)
+	cmd.Flags().StringSliceVarP(&filterFactions, "factions", "", nil, `Only generate these faction IDs, e.g. "anarch,neutral_runner"`)
 }
 
 var rootCmd = &cobra.Command{
@@ -168,13 +303,18 @@ var rootCmd = &cobra.Command{
 	Long: `A generative art tool to create alternate art cards with tournament legal frames for Netrunner.
   Complete documentation is available at https://github.com/mangofeet/netrunner-alt-gen`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		canvasWidth *= scaleFactor
-		canvasHeight *= scaleFactor
-		cardWidth *= scaleFactor
-		cardHeight *= scaleFactor
-		safeWidth *= scaleFactor
-		safeHeight *= scaleFactor
-		basic.ScaleFactor = scaleFactor
+		if err := loadConfig(cmd); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+
+		if err := applyOutputFlags(); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+
+		activeCmd, commandArgs = cmd, args
+		cardBackParams = changedFlags(cmd)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Println("Version:", version)
@@ -182,7 +322,53 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// applyOutputFlags checks and normalizes the output flags
+func applyOutputFlags() error {
+	outputFormat = strings.ToLower(strings.TrimPrefix(outputFormat, "."))
+	if outputFormat == "jpeg" || outputFormat == "tif" {
+		outputFormat = map[string]string{"jpeg": "jpg", "tif": "tiff"}[outputFormat]
+	}
+	if !slices.Contains(outputFormats, outputFormat) {
+		return fmt.Errorf(`unknown format "%s", expected one of %s`, outputFormat, strings.Join(outputFormats, ", "))
+	}
+
+	if !slices.Contains(cropModes, cropMode) {
+		return fmt.Errorf(`unknown crop "%s", expected one of %s`, cropMode, strings.Join(cropModes, ", "))
+	}
+	if !slices.Contains(layerFormats, layerFormat) {
+		return fmt.Errorf(`unknown layers format "%s", expected one of %s`, layerFormat, strings.Join(layerFormats, ", "))
+	}
+	if err := checkFileNameTemplate(fileNameTemplate); err != nil {
+		return err
+	}
+	if err := checkSeedFlags(); err != nil {
+		return err
+	}
+	if !slices.Contains(animateFormats, animateFormat) {
+		return fmt.Errorf(`unknown animation format "%s", expected one of %s`, animateFormat, strings.Join(animateFormats, ", "))
+	}
+	if animateEvery <= 0 || animateFPS <= 0 || animateWidth <= 0 {
+		return fmt.Errorf("--animate-every, --animate-fps and --animate-width must be greater than 0")
+	}
+	if previewRoundedCorners && isVectorFormat() {
+		return fmt.Errorf("--preview-rounded-corners only works with raster formats, not %s", outputFormat)
+	}
+
+	if dpi == layout.DesignDPI && scaleFactor != 1 {
+		dpi = layout.DesignDPI * scaleFactor
+	}
+	if dpi <= 0 {
+		return fmt.Errorf("--dpi must be greater than 0, got %g", dpi)
+	}
+	layout.DPI = dpi
+
+	return nil
+}
+
 func Execute() {
+	stop := catchInterrupt()
+	defer stop()
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
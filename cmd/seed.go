@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	"github.com/mangofeet/netrunner-alt-gen/art"
+)
+
+// algorithmSeedFields are the card fields an algorithm seeds from when
+// it isn't all of them
+var algorithmSeedFields = map[string][]string{
+	"tracker": {"title"},
+}
+
+// checkSeedFlags makes sure --seed-fields are known card fields and
+// --variant isn't negative
+func checkSeedFlags() error {
+	if seedVariant < 0 {
+		return fmt.Errorf("--variant must be 0 or more, got %d", seedVariant)
+	}
+	for i, field := range seedFields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !slices.Contains(art.SeedFields, field) {
+			return fmt.Errorf(`unknown seed field "%s", expected any of %s`, field, strings.Join(art.SeedFields, ", "))
+		}
+		seedFields[i] = field
+	}
+	return nil
+}
+
+// seedFields are the fields the art of the card is seeded from,
+// --seed-fields or the algorithm's own
+func (r cardRender) seedFields() []string {
+	if len(seedFields) > 0 {
+		return seedFields
+	}
+	if fields, ok := algorithmSeedFields[r.algorithm]; ok {
+		return fields
+	}
+	return art.SeedFields
+}
+
+// seed is what the art of the card is drawn from, --seed or the card
+// fields, made into the card's variant
+func (r cardRender) seed() string {
+	if r.effectiveSeed != "" {
+		return r.effectiveSeed
+	}
+	seed := seedOverride
+	if !seedGiven {
+		seed = art.CardSeed(r.card, r.seedFields())
+	}
+	return art.VariantSeed(seed, r.variant)
+}
+
+// seedFlag is --seed, which keeps track of being given at all so an
+// empty seed isn't mistaken for no seed
+type seedFlag struct{}
+
+func (seedFlag) String() string { return seedOverride }
+func (seedFlag) Type() string   { return "string" }
+
+func (seedFlag) Set(value string) error {
+	seedOverride, seedGiven = value, true
+	return nil
+}
+
+// seedLabel says where the seed came from, short enough for the card
+// back. It's empty for cards without random art.
+func (r cardRender) seedLabel() string {
+	if r.algorithm == "" || r.algorithm == "empty" {
+		return ""
+	}
+
+	label := fmt.Sprintf(`"%s"`, seedOverride)
+	if !seedGiven {
+		label = strings.Join(r.seedFields(), "+")
+	}
+	if r.variant > 0 {
+		label += fmt.Sprintf(" variant %d", r.variant)
+	}
+	return fmt.Sprintf("%s (%s)", label, seedFingerprint(r.seed()))
+}
+
+// seedFingerprint is a short hash of a seed, cards that would come out
+// the same share it
+func seedFingerprint(seed string) string {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// withSeedFlags sets --seed and --seed-fields for the rest of a test
+func withSeedFlags(t *testing.T, seed string, fields []string) {
+	t.Helper()
+	origSeed, origGiven, origFields := seedOverride, seedGiven, seedFields
+	t.Cleanup(func() { seedOverride, seedGiven, seedFields = origSeed, origGiven, origFields })
+	seedOverride, seedGiven, seedFields = seed, seed != "", fields
+}
+
+func seedCard() *nrdb.Printing {
+	card := &nrdb.Printing{}
+	card.ID = "01050"
+	card.Attributes = &nrdb.PrintingAttributes{
+		CardAttributes: nrdb.CardAttributes{
+			Title:      "Hedge Fund",
+			CardTypeID: "operation",
+			FactionID:  "neutral_corp",
+		},
+		Text: "Gain 9[credit].",
+	}
+	return card
+}
+
+func TestCardRenderSeed(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		seed      string
+		fields    []string
+		algorithm string
+		variant   int
+		wantSeed  string
+		wantLabel string
+	}{
+		{
+			name:      "card fields",
+			algorithm: "netwalker",
+			wantSeed:  "Hedge FundGain 9[credit].operationneutral_corp",
+			wantLabel: "title+text+type+faction+flavor",
+		},
+		{
+			name:      "tracker seeds from the title",
+			algorithm: "tracker",
+			wantSeed:  "Hedge Fund",
+			wantLabel: "title",
+		},
+		{
+			name:      "seed fields",
+			fields:    []string{"faction", "type"},
+			algorithm: "tracker",
+			wantSeed:  "operationneutral_corp",
+			wantLabel: "faction+type",
+		},
+		{
+			name:      "seed",
+			seed:      "my seed",
+			fields:    []string{"title"},
+			algorithm: "netwalker",
+			wantSeed:  "my seed",
+			wantLabel: `"my seed"`,
+		},
+		{
+			name:      "variant",
+			algorithm: "tracker",
+			variant:   2,
+			wantSeed:  "Hedge Fund#2",
+			wantLabel: "title variant 2",
+		},
+		{
+			name:      "variant of a seed",
+			seed:      "my seed",
+			algorithm: "netwalker",
+			variant:   1,
+			wantSeed:  "my seed#1",
+			wantLabel: `"my seed" variant 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSeedFlags(t, tt.seed, tt.fields)
+
+			r := newCardRender(seedCard(), tt.algorithm, "")
+			r.variant = tt.variant
+
+			if got := r.seed(); got != tt.wantSeed {
+				t.Errorf("got seed %q, want %q", got, tt.wantSeed)
+			}
+			wantLabel := tt.wantLabel + " (" + seedFingerprint(tt.wantSeed) + ")"
+			if got := r.seedLabel(); got != wantLabel {
+				t.Errorf("got label %q, want %q", got, wantLabel)
+			}
+		})
+	}
+
+	// cards without random art have no seed to show
+	if label := newCardRender(seedCard(), "empty", "").seedLabel(); label != "" {
+		t.Errorf("got label %q for the empty algorithm", label)
+	}
+}
+
+func TestEmptySeedFlag(t *testing.T) {
+
+	withSeedFlags(t, "", nil)
+	r := newCardRender(seedCard(), "netwalker", "")
+	cardSeed := r.seed()
+
+	// given through the flag set like the command line, the config
+	// file and reproduce do
+	flag := rootCmd.PersistentFlags().Lookup("seed")
+	t.Cleanup(func() { flag.Changed = false })
+	if err := rootCmd.PersistentFlags().Set("seed", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !seedGiven {
+		t.Fatal("an empty --seed isn't recorded as given")
+	}
+	if got := r.seed(); got != "" || got == cardSeed {
+		t.Errorf("got seed %q, want the empty seed", got)
+	}
+	if got, want := r.seedLabel(), `"" (`+seedFingerprint("")+")"; got != want {
+		t.Errorf("got label %q, want %q", got, want)
+	}
+}
+
+func TestCheckSeedFlags(t *testing.T) {
+
+	tests := []struct {
+		fields  []string
+		variant int
+		wantErr bool
+	}{
+		{fields: []string{"title", "flavor"}},
+		{fields: []string{" Title "}},
+		{fields: []string{"subtitle"}, wantErr: true},
+		{variant: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		withSeedFlags(t, "", tt.fields)
+		origVariant := seedVariant
+		seedVariant = tt.variant
+
+		err := checkSeedFlags()
+		seedVariant = origVariant
+		if tt.wantErr && err == nil {
+			t.Errorf("%v, variant %d: expected an error", tt.fields, tt.variant)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%v, variant %d: %s", tt.fields, tt.variant, err)
+		}
+	}
+}
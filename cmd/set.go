@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mangofeet/netrunner-alt-gen/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set [card set or cycle ID]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Generate every card in a set or cycle",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := generateSet(args[0]); err != nil {
+			log.Println("error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func generateSet(setID string) error {
+	src, err := getCardSource()
+	if err != nil {
+		return err
+	}
+
+	printings, err := source.InSet(src, setID)
+	if err != nil {
+		return fmt.Errorf("getting printings for %s: %w", setID, err)
+	}
+	if len(printings) == 0 {
+		return fmt.Errorf("no printings found for %s", setID)
+	}
+
+	filtered, err := filterPrintings(printings)
+	if err != nil {
+		return err
+	}
+	log.Printf("found %d printings in %s, %d after filters", len(printings), setID, len(filtered))
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	return renderBatch(filtered)
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mangofeet/netrunner-alt-gen/layout"
+	"github.com/spf13/cobra"
+	"github.com/tdewolff/canvas"
+)
+
+// paperSizes are portrait page sizes in mm
+var paperSizes = map[string][2]float64{
+	"a4":      {210, 297},
+	"letter":  {215.9, 279.4},
+	"a3":      {297, 420},
+	"tabloid": {279.4, 431.8},
+}
+
+var (
+	cutMarkStyles = []string{"none", "marks", "lines"}
+	duplexModes   = []string{"none", "long", "short"}
+)
+
+const (
+	// most printers can't print closer to the edge than this
+	sheetMinMarginMM = 4.0
+
+	cutMarkOffsetMM = 1.0
+	cutMarkLengthMM = 5.0
+	cutMarkWidthMM  = 0.1
+)
+
+func sheetFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&paperSize, "paper", "", "a4", `Paper size, one of a4, letter, a3, tabloid or a custom WxH size in mm, or inches with an "in" suffix, e.g. 210x297 or 8.5x14in`)
+	cmd.Flags().BoolVarP(&sheetLandscape, "landscape", "", false, `Turn the paper sideways`)
+	cmd.Flags().IntVarP(&sheetColumns, "columns", "", 0, `Cards across the page, defaults to as many as fit`)
+	cmd.Flags().IntVarP(&sheetRows, "rows", "", 0, `Cards down the page, defaults to as many as fit`)
+	cmd.Flags().Float64VarP(&sheetGutter, "gutter", "", 0, `Space between cards in mm`)
+	cmd.Flags().StringVarP(&cutMarks, "cut-marks", "", "marks",
+		`Cut guides to print
+  none    no guides
+  marks   short marks in the page margin in line with every cut
+  lines   thin lines across the whole page along every cut`)
+	cmd.Flags().BoolVarP(&sheetBleed, "include-bleed", "", false, `Print each card with its 1/8" bleed around the cut line`)
+	cmd.Flags().StringVarP(&duplex, "duplex", "", "none",
+		`Add a page of card backs after every page of fronts, for double sided printing
+  none    no back pages
+  long    backs line up when the paper flips on its long edge
+  short   backs line up when the paper flips on its short edge`)
+	cmd.Flags().BoolVarP(&inkSaver, "ink-saver", "", false, `Print black and white frames without art to save ink, ignores --algorithm and the colors`)
+	cmd.Flags().Float64SliceVarP(&registrationOffset, "registration-offset", "", nil, `Shift back pages by X,Y mm to make up for a printer's duplex misalignment, positive values move right and up`)
+}
+
+// sheetLayout places cards in a grid centered on the page, all sizes
+// are in mm
+type sheetLayout struct {
+	paper            string
+	pageW, pageH     float64
+	cardW, cardH     float64
+	bleed            float64
+	columns, rows    int
+	gutter           float64
+	left, bottom     float64
+	backDX, backDY   float64
+	mirrorX          bool
+	cutMarks, duplex string
+}
+
+func newSheetLayout() (sheetLayout, error) {
+	s := sheetLayout{
+		paper:    paperSize,
+		cardW:    layout.CardWidthMM,
+		cardH:    layout.CardHeightMM,
+		columns:  sheetColumns,
+		rows:     sheetRows,
+		gutter:   sheetGutter,
+		cutMarks: cutMarks,
+		duplex:   duplex,
+	}
+
+	var err error
+	s.pageW, s.pageH, err = parsePaperSize(paperSize)
+	if err != nil {
+		return s, err
+	}
+	if sheetLandscape {
+		s.pageW, s.pageH = s.pageH, s.pageW
+	}
+
+	if sheetBleed {
+		s.bleed = layout.BleedMM
+		s.cardW, s.cardH = layout.CanvasWidthMM, layout.CanvasHeightMM
+	}
+
+	if !slices.Contains(cutMarkStyles, s.cutMarks) {
+		return s, fmt.Errorf(`unknown cut marks "%s", expected one of %s`, s.cutMarks, strings.Join(cutMarkStyles, ", "))
+	}
+	if !slices.Contains(duplexModes, s.duplex) {
+		return s, fmt.Errorf(`unknown duplex mode "%s", expected one of %s`, s.duplex, strings.Join(duplexModes, ", "))
+	}
+	if s.gutter < 0 {
+		return s, fmt.Errorf("--gutter can't be negative, got %g", s.gutter)
+	}
+
+	switch len(registrationOffset) {
+	case 0:
+	case 2:
+		s.backDX, s.backDY = registrationOffset[0], registrationOffset[1]
+	default:
+		return s, fmt.Errorf("--registration-offset takes an X,Y pair, got %d values", len(registrationOffset))
+	}
+
+	if s.columns, err = sheetFit("columns", s.columns, s.pageW, s.cardW, s.gutter); err != nil {
+		return s, err
+	}
+	if s.rows, err = sheetFit("rows", s.rows, s.pageH, s.cardH, s.gutter); err != nil {
+		return s, err
+	}
+
+	s.left = (s.pageW - s.gridWidth()) / 2
+	s.bottom = (s.pageH - s.gridHeight()) / 2
+
+	// the paper turns around a vertical edge, mirroring the columns,
+	// for long edge duplex on portrait pages and short edge duplex on
+	// landscape ones, otherwise the rows are mirrored
+	s.mirrorX = (s.duplex == "long") == (s.pageH >= s.pageW)
+
+	return s, nil
+}
+
+// parsePaperSize reads a preset name or a WxH size in mm or inches
+func parsePaperSize(size string) (float64, float64, error) {
+	value := strings.ToLower(strings.TrimSpace(size))
+	if preset, ok := paperSizes[value]; ok {
+		return preset[0], preset[1], nil
+	}
+
+	unit := 1.0
+	if strings.HasSuffix(value, "in") {
+		unit = 25.4
+		value = strings.TrimSuffix(value, "in")
+	}
+	value = strings.TrimSuffix(value, "mm")
+
+	wStr, hStr, ok := strings.Cut(value, "x")
+	w, wErr := strconv.ParseFloat(strings.TrimSpace(wStr), 64)
+	h, hErr := strconv.ParseFloat(strings.TrimSpace(hStr), 64)
+	if !ok || wErr != nil || hErr != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf(`unknown paper size "%s", expected a4, letter, a3, tabloid or WxH like 210x297 or 8.5x11in`, size)
+	}
+
+	return w * unit, h * unit, nil
+}
+
+// sheetFit checks that count cards fit along a page side, or works out
+// how many fit inside the printable area when count is 0
+func sheetFit(name string, count int, page, card, gutter float64) (int, error) {
+	if count < 0 {
+		return 0, fmt.Errorf("--%s can't be negative, got %d", name, count)
+	}
+
+	if count == 0 {
+		count = int(math.Floor((page - 2*sheetMinMarginMM + gutter) / (card + gutter)))
+		if count < 1 {
+			return 0, fmt.Errorf("a %.1fmm card doesn't fit on a %.1fmm page", card, page)
+		}
+		return count, nil
+	}
+
+	if size := float64(count)*card + float64(count-1)*gutter; size > page {
+		return 0, fmt.Errorf("%d %s of cards need %.1fmm but the page is only %.1fmm", count, name, size, page)
+	}
+	return count, nil
+}
+
+func (s sheetLayout) gridWidth() float64 {
+	return float64(s.columns)*s.cardW + float64(s.columns-1)*s.gutter
+}
+
+func (s sheetLayout) gridHeight() float64 {
+	return float64(s.rows)*s.cardH + float64(s.rows-1)*s.gutter
+}
+
+func (s sheetLayout) perPage() int {
+	return s.columns * s.rows
+}
+
+func (s sheetLayout) String() string {
+	return fmt.Sprintf("%dx%d cards on %s (%g x %gmm)", s.columns, s.rows, s.paper, s.pageW, s.pageH)
+}
+
+// slot is the bottom left corner of the i-th card on a page, cards
+// fill the page left to right and top to bottom
+func (s sheetLayout) slot(i int) (float64, float64) {
+	col, row := i%s.columns, i/s.columns
+	x := s.left + float64(col)*(s.cardW+s.gutter)
+	y := s.bottom + s.gridHeight() - float64(row+1)*s.cardH - float64(row)*s.gutter
+	return x, y
+}
+
+// backSlot is where the back of the i-th card goes on the back page so
+// it lands behind the front once the paper is flipped
+func (s sheetLayout) backSlot(i int) (float64, float64) {
+	x, y := s.slot(i)
+	if s.mirrorX {
+		x = s.pageW - x - s.cardW
+	} else {
+		y = s.pageH - y - s.cardH
+	}
+	return x + s.backDX, y + s.backDY
+}
+
+// cuts are the positions of every cut line across and down the page
+func (s sheetLayout) cuts() ([]float64, []float64) {
+	var xs, ys []float64
+	add := func(list []float64, v float64) []float64 {
+		v = math.Round(v*1000) / 1000
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+		return list
+	}
+
+	for col := 0; col < s.columns; col++ {
+		x, _ := s.slot(col)
+		xs = add(xs, x+s.bleed)
+		xs = add(xs, x+s.cardW-s.bleed)
+	}
+	for row := 0; row < s.rows; row++ {
+		_, y := s.slot(row * s.columns)
+		ys = add(ys, y+s.bleed)
+		ys = add(ys, y+s.cardH-s.bleed)
+	}
+
+	return xs, ys
+}
+
+// drawCutMarks draws the --cut-marks guides on a page of fronts
+func (s sheetLayout) drawCutMarks(ctx *canvas.Context) {
+	if s.cutMarks == "none" {
+		return
+	}
+
+	xs, ys := s.cuts()
+
+	ctx.Push()
+	defer ctx.Pop()
+	ctx.SetFillColor(canvas.Transparent)
+	ctx.SetStrokeColor(canvas.Black)
+	ctx.SetStrokeWidth(cutMarkWidthMM)
+
+	if s.cutMarks == "lines" {
+		for _, x := range xs {
+			ctx.MoveTo(x, 0)
+			ctx.LineTo(x, s.pageH)
+		}
+		for _, y := range ys {
+			ctx.MoveTo(0, y)
+			ctx.LineTo(s.pageW, y)
+		}
+		ctx.Stroke()
+		return
+	}
+
+	top, right := s.bottom+s.gridHeight(), s.left+s.gridWidth()
+	lengthX := math.Min(cutMarkLengthMM, s.left-cutMarkOffsetMM)
+	lengthY := math.Min(cutMarkLengthMM, s.bottom-cutMarkOffsetMM)
+	if lengthX <= 0 || lengthY <= 0 {
+		log.Println("warning: no room for cut marks in the page margin, try --cut-marks lines")
+		return
+	}
+
+	for _, x := range xs {
+		ctx.MoveTo(x, s.bottom-cutMarkOffsetMM)
+		ctx.LineTo(x, s.bottom-cutMarkOffsetMM-lengthY)
+		ctx.MoveTo(x, top+cutMarkOffsetMM)
+		ctx.LineTo(x, top+cutMarkOffsetMM+lengthY)
+	}
+	for _, y := range ys {
+		ctx.MoveTo(s.left-cutMarkOffsetMM, y)
+		ctx.LineTo(s.left-cutMarkOffsetMM-lengthX, y)
+		ctx.MoveTo(right+cutMarkOffsetMM, y)
+		ctx.LineTo(right+cutMarkOffsetMM+lengthX, y)
+	}
+	ctx.Stroke()
+}
+
+// cardImage rasterizes a rendered card, cut down to the trim line
+// unless the bleed is printed
+func (s sheetLayout) cardImage(cnv *canvas.Canvas) image.Image {
+	img := image.Image(layout.Rasterize(cnv))
+
+	if s.bleed == 0 {
+		trim := pixelRect(centeredRect(cardWidth, cardHeight))
+		cropped := image.NewRGBA(image.Rect(0, 0, trim.Width, trim.Height))
+		draw.Draw(cropped, cropped.Bounds(), img, image.Pt(trim.X, trim.Y), draw.Src)
+		img = cropped
+	}
+
+	return img
+}
+
+// drawCard places a card image from cardImage at x, y
+func (s sheetLayout) drawCard(ctx *canvas.Context, x, y float64, img image.Image) {
+	dpmm := float64(img.Bounds().Dx()) / s.cardW
+	ctx.DrawImage(x, y, img, canvas.DPMM(dpmm))
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePaperSize(t *testing.T) {
+
+	tests := []struct {
+		size    string
+		wantW   float64
+		wantH   float64
+		wantErr bool
+	}{
+		{size: "a4", wantW: 210, wantH: 297},
+		{size: " Letter ", wantW: 215.9, wantH: 279.4},
+		{size: "100x150", wantW: 100, wantH: 150},
+		{size: "100x150mm", wantW: 100, wantH: 150},
+		{size: "8.5x14in", wantW: 215.9, wantH: 355.6},
+		{size: "a5", wantErr: true},
+		{size: "100", wantErr: true},
+		{size: "0x150", wantErr: true},
+		{size: "ax150", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		w, h, err := parsePaperSize(tt.size)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.size)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", tt.size, err)
+			continue
+		}
+		if math.Abs(w-tt.wantW) > 1e-9 || math.Abs(h-tt.wantH) > 1e-9 {
+			t.Errorf("%q: got %gx%g, want %gx%g", tt.size, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+// sheetOptions are the sheet flags, set for the duration of a test
+type sheetOptions struct {
+	paper              string
+	landscape, bleed   bool
+	columns, rows      int
+	gutter             float64
+	cutMarks, duplex   string
+	registrationOffset []float64
+}
+
+func testSheetLayout(t *testing.T, opts sheetOptions) (sheetLayout, error) {
+	t.Helper()
+
+	orig := sheetOptions{paperSize, sheetLandscape, sheetBleed, sheetColumns, sheetRows, sheetGutter, cutMarks, duplex, registrationOffset}
+	t.Cleanup(func() {
+		paperSize, sheetLandscape, sheetBleed = orig.paper, orig.landscape, orig.bleed
+		sheetColumns, sheetRows, sheetGutter = orig.columns, orig.rows, orig.gutter
+		cutMarks, duplex, registrationOffset = orig.cutMarks, orig.duplex, orig.registrationOffset
+	})
+
+	if opts.paper == "" {
+		opts.paper = "a4"
+	}
+	if opts.cutMarks == "" {
+		opts.cutMarks = "marks"
+	}
+	if opts.duplex == "" {
+		opts.duplex = "none"
+	}
+	paperSize, sheetLandscape, sheetBleed = opts.paper, opts.landscape, opts.bleed
+	sheetColumns, sheetRows, sheetGutter = opts.columns, opts.rows, opts.gutter
+	cutMarks, duplex, registrationOffset = opts.cutMarks, opts.duplex, opts.registrationOffset
+
+	return newSheetLayout()
+}
+
+func TestSheetLayoutFit(t *testing.T) {
+
+	tests := []struct {
+		name                  string
+		opts                  sheetOptions
+		wantColumns, wantRows int
+		wantErr               bool
+	}{
+		{name: "a4", opts: sheetOptions{}, wantColumns: 3, wantRows: 3},
+		{name: "letter landscape", opts: sheetOptions{paper: "letter", landscape: true}, wantColumns: 4, wantRows: 2},
+		{name: "bleed", opts: sheetOptions{bleed: true}, wantColumns: 2, wantRows: 3},
+		{name: "gutter", opts: sheetOptions{gutter: 5}, wantColumns: 3, wantRows: 3},
+		{name: "fixed grid", opts: sheetOptions{columns: 2, rows: 1}, wantColumns: 2, wantRows: 1},
+		{name: "too many columns", opts: sheetOptions{columns: 4}, wantErr: true},
+		{name: "negative rows", opts: sheetOptions{rows: -1}, wantErr: true},
+		{name: "negative gutter", opts: sheetOptions{gutter: -1}, wantErr: true},
+		{name: "page too small", opts: sheetOptions{paper: "50x50"}, wantErr: true},
+		{name: "unknown cut marks", opts: sheetOptions{cutMarks: "dots"}, wantErr: true},
+		{name: "unknown duplex", opts: sheetOptions{duplex: "both"}, wantErr: true},
+		{name: "half a registration offset", opts: sheetOptions{registrationOffset: []float64{1}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := testSheetLayout(t, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.columns != tt.wantColumns || s.rows != tt.wantRows {
+				t.Errorf("got %dx%d, want %dx%d", s.columns, s.rows, tt.wantColumns, tt.wantRows)
+			}
+
+			// the grid is centered and inside the page
+			right := s.pageW - s.left - s.gridWidth()
+			top := s.pageH - s.bottom - s.gridHeight()
+			if math.Abs(s.left-right) > 1e-9 || math.Abs(s.bottom-top) > 1e-9 || s.left < 0 || s.bottom < 0 {
+				t.Errorf("grid isn't centered, margins %g %g %g %g", s.left, right, s.bottom, top)
+			}
+		})
+	}
+}
+
+func TestSheetLayoutSlots(t *testing.T) {
+
+	s, err := testSheetLayout(t, sheetOptions{gutter: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first card is top left, the next one to its right and the
+	// first of the second row below it
+	x0, y0 := s.slot(0)
+	x1, y1 := s.slot(1)
+	x3, y3 := s.slot(s.columns)
+	if x0 != s.left || math.Abs(y0+s.cardH-(s.bottom+s.gridHeight())) > 1e-9 {
+		t.Errorf("first card at %g,%g isn't top left", x0, y0)
+	}
+	if math.Abs(x1-(x0+s.cardW+s.gutter)) > 1e-9 || y1 != y0 {
+		t.Errorf("second card at %g,%g isn't right of the first", x1, y1)
+	}
+	if x3 != x0 || math.Abs(y3-(y0-s.cardH-s.gutter)) > 1e-9 {
+		t.Errorf("first card of the second row at %g,%g isn't below the first", x3, y3)
+	}
+
+	xs, ys := s.cuts()
+	if len(xs) != 2*s.columns || len(ys) != 2*s.rows {
+		t.Errorf("got %d and %d cuts with a gutter, want %d and %d", len(xs), len(ys), 2*s.columns, 2*s.rows)
+	}
+
+	// without a gutter neighbouring cards share a cut
+	s, err = testSheetLayout(t, sheetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xs, ys = s.cuts()
+	if len(xs) != s.columns+1 || len(ys) != s.rows+1 {
+		t.Errorf("got %d and %d cuts, want %d and %d", len(xs), len(ys), s.columns+1, s.rows+1)
+	}
+}
+
+func TestSheetLayoutDuplex(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		opts        sheetOptions
+		wantMirrorX bool
+	}{
+		{name: "long edge portrait", opts: sheetOptions{duplex: "long"}, wantMirrorX: true},
+		{name: "short edge portrait", opts: sheetOptions{duplex: "short"}, wantMirrorX: false},
+		{name: "long edge landscape", opts: sheetOptions{duplex: "long", landscape: true}, wantMirrorX: false},
+		{name: "short edge landscape", opts: sheetOptions{duplex: "short", landscape: true}, wantMirrorX: true},
+		{name: "registration offset", opts: sheetOptions{duplex: "long", registrationOffset: []float64{1.5, -0.5}}, wantMirrorX: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := testSheetLayout(t, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var dx, dy float64
+			if len(tt.opts.registrationOffset) == 2 {
+				dx, dy = tt.opts.registrationOffset[0], tt.opts.registrationOffset[1]
+			}
+
+			// flipping the page over the right edge mirrors x, over the
+			// bottom edge mirrors y, the back has to land on the front
+			for i := 0; i < s.perPage(); i++ {
+				x, y := s.slot(i)
+				bx, by := s.backSlot(i)
+				wantX, wantY := x, s.pageH-y-s.cardH
+				if tt.wantMirrorX {
+					wantX, wantY = s.pageW-x-s.cardW, y
+				}
+				if math.Abs(bx-wantX-dx) > 1e-9 || math.Abs(by-wantY-dy) > 1e-9 {
+					t.Errorf("back of card %d at %g,%g, want %g,%g", i, bx, by, wantX+dx, wantY+dy)
+				}
+			}
+		})
+	}
+}
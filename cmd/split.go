@@ -5,13 +5,15 @@ import (
 	"os"
 	"strings"
 
+	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/art/reflection"
+	"github.com/mangofeet/nrdb-go"
 	"github.com/spf13/cobra"
 )
 
 var reflectionCmd = &cobra.Command{
 	Use:   "reflection [card name or printing ID]",
-	Args:  cobra.MinimumNArgs(1),
+	Args:  cardArgs(1),
 	Short: `Generate a card using the "reflection" algorithm`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -26,16 +28,14 @@ var reflectionCmd = &cobra.Command{
 }
 
 func generateCardReflection(cardName string) error {
-	printing, err := getCardData(cardName)
-	if err != nil {
-		return err
-	}
-	log.Printf("generating %s", printing.Attributes.StrippedTitle)
+	return forEachCard(cardName, "reflection", func(printing *nrdb.Printing) error {
+		return generateCard(reflectionDrawer(), printing, "reflection", "mangofeet")
+	})
+}
 
-	ns := reflection.Reflection{
+func reflectionDrawer() art.Drawer {
+	return reflection.Reflection{
 		Color:   parseColor(baseColor),
 		ColorBG: parseColor(colorBG),
 	}
-
-	return generateCard(ns, printing, "reflection", "mangofeet")
 }
@@ -1,16 +1,14 @@
 package cmd
 
 import (
-	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/art/tracker"
-	"github.com/mangofeet/nrdb-go"
+	"github.com/mangofeet/netrunner-alt-gen/internal/source"
 	"github.com/spf13/cobra"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
 var trackerCmd = &cobra.Command{
@@ -31,23 +29,21 @@ var trackerCmd = &cobra.Command{
 
 func generateCardTracker(cardName string) error {
 
-	// construct a "card" to use for the printing
-	printing := &nrdb.Printing{
-		Document: nrdb.Document[nrdb.PrintingAttributes, nrdb.PrintingRelationships]{
-			ID: "0",
-			Attributes: &nrdb.PrintingAttributes{
-				CardAttributes: nrdb.CardAttributes{
-					Title:         cases.Title(language.English).String(cardName),
-					FactionID:     cardName, // allows auto coloring things
-					StrippedTitle: fmt.Sprintf("%s tracker", cardName),
-				},
-				CardSetID:     "components",
-				PositionInSet: 0,
-			},
-		},
+	printing, err := source.Trackers().ByPrintingID(cardName)
+	if err != nil {
+		return err
 	}
 
-	ns := tracker.Tracker{
+	// set the frame to be "tracker" specifically
+	if frame != "none" {
+		frame = frame + "-tracker"
+	}
+
+	return generateCard(trackerDrawer(), printing, "tracker", "mangofeet")
+}
+
+func trackerDrawer() art.Drawer {
+	return tracker.Tracker{
 		Color:            parseColor(baseColor),
 		ColorBG:          parseColor(colorBG),
 		OverlayRingColor: parseColor(overlayColor),
@@ -56,11 +52,4 @@ func generateCardTracker(cardName string) error {
 		RingColor3:       parseColor(altColor3),
 		RingColor4:       parseColor(altColor4),
 	}
-
-	// set the frame to be "tracker" specifically
-	if frame != "none" {
-		frame = frame + "-tracker"
-	}
-
-	return generateCard(ns, printing, "tracker", "mangofeet")
 }
@@ -10,22 +10,31 @@ import (
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/frame/basic"
+	"github.com/mangofeet/netrunner-alt-gen/internal/source"
 	"github.com/mangofeet/nrdb-go"
+	"github.com/spf13/cobra"
 	"github.com/tdewolff/canvas"
 )
 
-func getFramer(card *nrdb.Printing, algorithm, designer string) (art.Drawer, error) {
+func getFramer(r cardRender) (art.Drawer, error) {
 
-	if textBoxFactor > 1 {
-		textBoxFactor /= 100.0
+	card := r.card
+
+	// a percentage, or a fraction from before it was one
+	textBoxHeight := textBoxFactor
+	if textBoxHeight > 1 {
+		textBoxHeight /= 100.0
 	}
 
 	frm := basic.FrameBasic{
 		Version:   version,
-		Algorithm: algorithm,
-		Designer:  designer,
+		Algorithm: r.algorithm,
+		Designer:  r.designer,
+
+		Parameters: cardBackParams,
+		Seed:       r.seedLabel(),
 
-		TextBoxHeightFactor: &textBoxFactor,
+		TextBoxHeightFactor: &textBoxHeight,
 
 		ColorBG:               parseColor(frameColorBackground),
 		ColorBorder:           parseColor(frameColorBorder),
@@ -39,14 +48,14 @@ func getFramer(card *nrdb.Printing, algorithm, designer string) (art.Drawer, err
 		ColorMinDeckBG:        parseColorInstruction(frameColorMinDeckBG, card),
 	}
 
-	switch frame {
+	switch r.frame {
 	case "basic-back", "basic-tracker-back":
 		return frm.Back(), nil
 	case "basic-tracker":
 		return frm.Tracker(), nil
 	case "basic":
 		var framer art.Drawer
-		log.Printf("Card: %s, type: %s", card.Attributes.Title, card.Attributes.CardTypeID)
+		r.logger().Printf("Card: %s, type: %s", card.Attributes.Title, card.Attributes.CardTypeID)
 
 		if flavorText != "" {
 			frm.Flavor = "<em>" + flavorText + "</em>"
@@ -74,7 +83,7 @@ func getFramer(card *nrdb.Printing, algorithm, designer string) (art.Drawer, err
 					part = strings.Replace(part, "—", "- ", 1)
 					part = strings.Replace(part, "–", "- ", 1)
 					if part[0] == '-' {
-						log.Println("detected flavor text attribution")
+						r.logger().Println("detected flavor text attribution")
 						frm.FlavorAttribution = "<em>" + part + "</em>"
 					} else {
 						finalFlavor += "<BR>" + part
@@ -118,91 +127,236 @@ func getFramer(card *nrdb.Printing, algorithm, designer string) (art.Drawer, err
 		return art.NoopDrawer{}, nil
 	}
 
-	return nil, fmt.Errorf(`unknown frame type "%s"`, frame)
+	return nil, fmt.Errorf(`unknown frame type "%s"`, r.frame)
 }
 
-func getCardData(cardName string) (*nrdb.Printing, error) {
-	nrClient := nrdb.NewClient()
+var cardSource source.CardSource
+
+// getCardSource builds the source selected with --source once and
+// reuses it for every lookup
+func getCardSource() (source.CardSource, error) {
+	if cardSource != nil {
+		return cardSource, nil
+	}
 
-	if printingID, err := strconv.Atoi(cardName); err == nil {
-		printing, err := nrClient.Printing(cardName)
+	// a card file replaces every other source
+	if cardFile != "" {
+		src, err := source.LoadDefinitions(cardFile)
 		if err != nil {
-			return nil, fmt.Errorf("no result for printing ID %d", printingID)
+			return nil, err
 		}
+		cardSource = src
+		return cardSource, nil
+	}
+
+	spec := cardSourceSpec
+	if cardDB != "" {
+		spec = "dump:" + cardDB
+		if cardSourceSpec != "nrdb" {
+			spec += "," + cardSourceSpec
+		}
+	}
+
+	client, err := getNRDBClient()
+	if err != nil {
+		return nil, err
+	}
+
+	csvOptions, err := getCSVOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := source.Options{
+		Client: client,
+		CSV:    csvOptions,
+		Warn: func(err error) {
+			log.Println("warning:", err)
+		},
+	}
+
+	// title searches are only cached as typed, offline they fall
+	// back to the printings fetched so far, like with cache warm
+	if offline {
+		cached, err := cachedPrintings()
+		if err != nil {
+			return nil, fmt.Errorf("loading cached printings: %w", err)
+		}
+		opts.NRDBFallback = cached
+	}
+
+	src, err := source.Parse(spec, opts)
+	if err != nil {
+		return nil, fmt.Errorf("loading card source: %w", err)
+	}
+
+	// searches with no hits fall back to every printing, which nrdb
+	// downloads page by page
+	cardSource = source.RememberAll(src)
+	return cardSource, nil
+}
+
+func getCardData(cardName string) (*nrdb.Printing, error) {
+	src, err := getCardSource()
+	if err != nil {
+		return nil, err
+	}
+
+	if cardName == "" {
+		return getOnlyCard(src)
+	}
+
+	// printing IDs can be anything in custom cards, a name that isn't
+	// one is searched for. NRDB only gets asked for numeric IDs.
+	printing, idErr := src.ByPrintingID(cardName)
+	if idErr == nil {
 		return printing, nil
 	}
+	if _, err := strconv.Atoi(cardName); err == nil {
+		return nil, fmt.Errorf("no result for printing ID %s: %w", cardName, idErr)
+	}
 
-	cards, err := nrClient.Cards(&nrdb.CardFilter{
-		Search: &cardName,
-	})
+	matches, err := findCards(src, cardName)
 	if err != nil {
-		return nil, fmt.Errorf("getting card data: %w", err)
+		return nil, err
 	}
 
-	if len(cards) == 0 {
+	if len(matches) == 0 {
 		return nil, fmt.Errorf("no results for %s", cardName)
 	}
 
-	if len(cards) != 1 {
-		for _, card := range cards {
-			log.Printf("%s - %s", card.LatestPrintingID(), card.StrippedTitle())
+	var card *nrdb.Card
+	switch {
+	case pick > 0:
+		if pick > len(matches) {
+			logMatches(matches)
+			return nil, fmt.Errorf("--pick %d is out of range, there are %d results", pick, len(matches))
 		}
-		return nil, fmt.Errorf("mulitple results, run using the printing ID from the options above")
+		card = matches[pick-1].Card
+	case len(matches) == 1 || matches[0].Kind < matches[1].Kind:
+		card = matches[0].Card
+	default:
+		logMatches(matches)
+		return nil, fmt.Errorf("multiple results, run using --pick N or the printing ID from the options above")
 	}
 
-	card := cards[0]
+	return getPrinting(src, card)
 
-	printing, err := nrClient.Printing(card.LatestPrintingID())
+}
+
+// getPrinting returns the latest printing of a card, or the one from
+// the set given with --set
+func getPrinting(src source.CardSource, card *nrdb.Card) (*nrdb.Printing, error) {
+	if printingSet != "" {
+		return getPrintingInSet(src, card, printingSet)
+	}
+
+	printing, err := src.ByPrintingID(card.LatestPrintingID())
 	if err != nil {
 		return nil, fmt.Errorf("getting latest printing data: %w", err)
 	}
 
 	return printing, nil
+}
 
+func getCSVOptions() (source.CSVOptions, error) {
+	opts := source.CSVOptions{
+		StartRow: startRow,
+	}
+
+	if csvColumns != "" {
+		columns, err := source.LoadColumnMapping(csvColumns)
+		if err != nil {
+			return opts, fmt.Errorf("loading column mapping: %w", err)
+		}
+		opts.Columns = columns
+	}
+
+	return opts, nil
 }
 
-var fileNameRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+// getOnlyCard is used when no card name is given, which is fine for
+// a card file holding a single card
+func getOnlyCard(src source.CardSource) (*nrdb.Printing, error) {
+	printings, err := src.All()
+	if err != nil {
+		return nil, err
+	}
 
-func getFileName(card *nrdb.Printing) string {
+	switch len(printings) {
+	case 0:
+		return nil, fmt.Errorf("no cards found")
+	case 1:
+		return printings[0], nil
+	}
 
-	pos := fmt.Sprint(card.Attributes.PositionInSet)
+	for _, printing := range printings {
+		log.Printf("%s - %s", printing.ID, printing.Attributes.Title)
+	}
+	return nil, fmt.Errorf("%d cards found, run using a name or ID from the options above", len(printings))
+}
 
-	if card.Attributes.PositionInSet < 10 {
-		pos = fmt.Sprintf("00%d", card.Attributes.PositionInSet)
-	} else if card.Attributes.PositionInSet < 100 {
-		pos = fmt.Sprintf("0%d", card.Attributes.PositionInSet)
+// cardArgs requires n arguments, where the last one is the card name,
+// which can be left off when the cards come from --card-file or
+// --query
+func cardArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if cardFile != "" || cardQuery != "" {
+			return cobra.MinimumNArgs(n-1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(n)(cmd, args)
 	}
+}
+
+// findCards searches the card source and ranks the results, falling
+// back to comparing against every card to catch typos when the search
+// itself finds nothing
+func findCards(src source.CardSource, cardName string) ([]source.Match, error) {
 
-	cardIDInt, err := strconv.Atoi(card.ID)
+	cards, err := src.Search(cardName)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("getting card data: %w", err)
 	}
-	cardID := fmt.Sprint(card.ID)
 
-	if cardIDInt < 10 {
-		cardID = fmt.Sprintf("0000%s", card.ID)
-	} else if cardIDInt < 100 {
-		cardID = fmt.Sprintf("000%s", card.ID)
-	} else if cardIDInt < 1000 {
-		cardID = fmt.Sprintf("00%s", card.ID)
-	} else if cardIDInt < 10000 {
-		cardID = fmt.Sprintf("0%s", card.ID)
+	if len(cards) > 0 {
+		return source.Rank(cardName, cards), nil
 	}
 
-	title := fileNameRegexp.ReplaceAllString(strings.ReplaceAll(card.Attributes.StrippedTitle, ":", ""), "-")
-	title = strings.ToLower(title)
+	printings, err := src.All()
+	if err != nil {
+		return nil, fmt.Errorf("getting card data: %w", err)
+	}
 
-	set := fileNameRegexp.ReplaceAllString(card.Attributes.CardSetID, "-")
+	return source.Rank(cardName, source.Cards(printings)), nil
+}
 
-	back := ""
-	if strings.Contains(frame, "back") {
-		back = "-back"
+func logMatches(matches []source.Match) {
+	for i, match := range matches {
+		log.Printf("%d: %s - %s (%s)", i+1, match.Card.LatestPrintingID(), match.Card.StrippedTitle(), match.Kind)
 	}
+}
 
-	return fmt.Sprintf("%s-%s-%s-%s%s", cardID, set, pos, title, back)
+// getPrintingInSet finds the printing of a card from a specific set
+func getPrintingInSet(src source.CardSource, card *nrdb.Card, setID string) (*nrdb.Printing, error) {
 
+	var sets []string
+	for _, printingID := range card.PrintingIDs() {
+		printing, err := src.ByPrintingID(printingID)
+		if err != nil {
+			return nil, fmt.Errorf("getting printing %s: %w", printingID, err)
+		}
+
+		if printing.Attributes.CardSetID == setID {
+			return printing, nil
+		}
+		sets = append(sets, printing.Attributes.CardSetID)
+	}
+
+	return nil, fmt.Errorf(`%s has no printing in set "%s", it was printed in: %s`, card.StrippedTitle(), setID, strings.Join(sets, ", "))
 }
 
+var fileNameRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
 func drawMargin(ctx *canvas.Context, x, y, w, h float64, c color.Color) {
 	_, canvasHeight := ctx.Size()
 
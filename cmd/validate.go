@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/mangofeet/netrunner-alt-gen/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [card file]...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Check custom card definition files for mistakes",
+	Long: `Check custom card definition files for mistakes.
+
+A card definition is a YAML or JSON file describing a card that isn't
+on NRDB. Render it by passing the file with --card-file to any of the
+generation commands, the card name can then be left off. A file holds
+either one card or a list of cards, with a list the card is chosen by
+title or ID.
+
+Required fields:
+  title                    card title
+  type                     agenda, asset, corp_identity, event, hardware,
+                           ice, operation, program, resource,
+                           runner_identity or upgrade
+  faction                  anarch, criminal, shaper, neutral_runner,
+                           haas_bioroid, jinteki, nbn, weyland_consortium
+                           or neutral_corp
+
+Optional fields:
+  id                       printing ID, defaults to custom-N for the Nth
+                           card in the file
+  set                      card set ID used in file names
+  position                 position in the set, defaults to the position
+                           in the file
+  unique                   true for unique cards
+  subtypes                 list of subtypes, e.g. [Code Gate, AP]
+  cost                     play, install or rez cost, a number or X
+  strength                 ice and program strength
+  memory_cost              program memory cost
+  trash_cost               asset and upgrade trash cost
+  influence                influence cost
+  agenda_points            agenda points
+  advancement_requirement  a number or X
+  link                     runner identity base link
+  memory_units             runner identity memory units, defaults to 4
+  minimum_deck_size        identity deck size, required for identities
+  influence_limit          identity influence limit, required for identities
+  deck_limit               copies allowed in a deck, defaults to 3
+  pronouns                 runner identity pronouns
+  text                     card text, using NRDB markup like [click]
+  flavor                   flavor text
+
+Example:
+
+  title: Hedge Fund 2
+  type: operation
+  faction: neutral_corp
+  subtypes: [Transaction]
+  cost: 5
+  influence: 0
+  text: Gain 9[credit].
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		failed := false
+		for _, path := range args {
+			if err := validateCardFile(path); err != nil {
+				failed = true
+				log.Println(err)
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func validateCardFile(path string) error {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	defs, err := source.ParseDefinitions(path, data)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range defs {
+		log.Printf("%s: %s (%s, %s) ok", path, def.Title, def.Type, def.Faction)
+	}
+
+	return nil
+}
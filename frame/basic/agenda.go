@@ -10,7 +10,7 @@ import (
 
 func (fb FrameBasic) Agenda() art.Drawer {
 
-	return art.DrawerFunc(func(ctx *canvas.Context, card *nrdb.Printing) error {
+	return fb.drawer(func(ctx *canvas.Context, card *nrdb.Printing) error {
 
 		canvasWidth, canvasHeight := ctx.Size()
 
@@ -3,7 +3,6 @@ package basic
 import (
 	"fmt"
 	"math"
-	"os"
 	"strings"
 
 	"github.com/mangofeet/netrunner-alt-gen/art"
@@ -13,7 +12,7 @@ import (
 
 func (fb FrameBasic) Back() art.Drawer {
 
-	return art.DrawerFunc(func(ctx *canvas.Context, card *nrdb.Printing) error {
+	return fb.drawer(func(ctx *canvas.Context, card *nrdb.Printing) error {
 
 		canvasWidth, canvasHeight := ctx.Size()
 
@@ -29,10 +28,13 @@ func (fb FrameBasic) Back() art.Drawer {
 		if fb.Algorithm == "" && fb.Designer != "" {
 			attributionString = fmt.Sprintf("%s<BR>Design by %s<BR>Layout by netrunner-alt-gen %s", card.Attributes.Title, fb.Designer, fb.Version)
 		}
+		if fb.Seed != "" {
+			attributionString += "<BR>Seed " + fb.Seed
+		}
 
 		cliFontSize := attributionFontSize * 0.9
 		cliTextMaxHeight := canvasHeight * 0.7
-		cliString := getCLIText()
+		cliString := strings.Join(fb.Parameters, "<BR>")
 
 		attributionText := fb.getVerticalFittedText(ctx, attributionString, attributionFontSize, 0, attributionTextMaxHeight, canvas.Center)
 		cliText := fb.getVerticalFittedText(ctx, cliString, cliFontSize, 0, cliTextMaxHeight, canvas.Left)
@@ -91,53 +93,3 @@ func (fb FrameBasic) drawRoundedBox(ctx *canvas.Context, top, bottom, left, righ
 	ctx.DrawPath(0, 0, path)
 	ctx.Pop()
 }
-
-func getCLIText() string {
-	var args []string
-
-	var isFlag bool
-	var thisOpt string
-	for _, arg := range os.Args {
-
-		if len(arg) < 1 {
-			thisOpt = ""
-			continue
-		}
-
-		if arg == "-o" {
-			thisOpt = ""
-			continue
-		}
-
-		if arg == "--make-back" {
-			thisOpt = ""
-			continue
-		}
-
-		if arg[0] == '-' {
-			isFlag = true
-		}
-
-		if !isFlag {
-			thisOpt = ""
-			continue
-		}
-
-		if arg[0] != '-' {
-			isFlag = false
-			if strings.Contains(arg, " ") {
-				if strings.Contains(arg, `"`) {
-					arg = "'" + arg + "'"
-				} else {
-					arg = `"` + arg + `"`
-				}
-			}
-			args = append(args, thisOpt+" "+arg)
-		} else {
-			thisOpt = arg
-		}
-
-	}
-
-	return strings.Join(args, "<BR>")
-}
@@ -13,6 +13,12 @@ type FrameBasic struct {
 	TextBoxHeightFactor          *float64
 	Designer, Version, Algorithm string
 
+	// Parameters are the command line flags listed on the card back
+	Parameters []string
+
+	// Seed says what the art was seeded with, for the card back
+	Seed string
+
 	ColorBG, ColorBorder, ColorText,
 	ColorTextStrength, ColorInfluencePips,
 	ColorInfluenceLimitBG, ColorMinDeckBG,
@@ -4,13 +4,31 @@ import (
 	"image/color"
 	"io"
 	"log"
+	"sync"
 
+	"github.com/mangofeet/netrunner-alt-gen/art"
 	"github.com/mangofeet/netrunner-alt-gen/assets"
+	"github.com/mangofeet/nrdb-go"
 	"github.com/tdewolff/canvas"
 )
 
 var fontFamily = canvas.NewFontFamily("cardtext")
 
+// fontLock is held while a frame lays out its text, the fonts shape
+// text with harfbuzz faces that can't be used from two goroutines at
+// once. Turning the laid out text into paths later on is safe.
+var fontLock sync.Mutex
+
+// drawer wraps the draw function of a frame so frames drawn at the
+// same time take turns with the fonts
+func (fb FrameBasic) drawer(draw art.DrawerFunc) art.Drawer {
+	return art.DrawerFunc(func(ctx *canvas.Context, card *nrdb.Printing) error {
+		fontLock.Lock()
+		defer fontLock.Unlock()
+		return draw(ctx, card)
+	})
+}
+
 func init() {
 
 	if err := loadFont("Ubuntu-R.ttf", "sans-serif", canvas.FontRegular); err != nil {
@@ -9,7 +9,7 @@ import (
 )
 
 func (fb FrameBasic) Operation() art.Drawer {
-	return art.DrawerFunc(func(ctx *canvas.Context, card *nrdb.Printing) error {
+	return fb.drawer(func(ctx *canvas.Context, card *nrdb.Printing) error {
 
 		canvasWidth, canvasHeight := ctx.Size()
 
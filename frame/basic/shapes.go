@@ -7,26 +7,32 @@ import (
 	"strings"
 
 	"github.com/mangofeet/netrunner-alt-gen/assets"
+	"github.com/mangofeet/netrunner-alt-gen/layout"
 	"github.com/mangofeet/nrdb-go"
 	"github.com/tdewolff/canvas"
 )
 
-var ScaleFactor float64
+// frame measurements from the edge of the canvas, in millimetres
+const (
+	strokeWidthMM        = 0.15
+	titleBoxHeightMM     = 5.9
+	titleBoxTopMM        = layout.BleedMM + 4.85
+	influenceHeightMM    = 26.4
+	runnerLimitsBottomMM = 5.65
+	runnerLimitsHeightMM = 5.55
+)
 
 func getStrokeWidth(ctx *canvas.Context) float64 {
-	_, canvasHeight := ctx.Size()
-	// return canvasHeight * 0.0023
-	return canvasHeight * 0.0016
+	return layout.Units(strokeWidthMM)
 }
 
 func getTitleBoxHeight(ctx *canvas.Context) float64 {
-	_, canvasHeight := ctx.Size()
-	return (canvasHeight / 16)
+	return layout.Units(titleBoxHeightMM)
 }
 
 func getTitleBoxTop(ctx *canvas.Context) float64 {
 	_, canvasHeight := ctx.Size()
-	return canvasHeight - (canvasHeight / 12)
+	return canvasHeight - layout.Units(titleBoxTopMM)
 }
 
 func getCostContainerRadius(ctx *canvas.Context) float64 {
@@ -38,27 +44,23 @@ func getCostContainerStart(ctx *canvas.Context) float64 {
 }
 
 func (fb FrameBasic) getTextBoxHeight(ctx *canvas.Context) float64 {
-	_, canvasHeight := ctx.Size()
 	factor := 0.3333
 	if fb.TextBoxHeightFactor != nil {
 		factor = *fb.TextBoxHeightFactor
 	}
-	return (canvasHeight * factor)
+	return layout.Units(layout.CanvasHeightMM * factor)
 }
 
 func getInfluenceHeight(ctx *canvas.Context) float64 {
-	_, canvasHeight := ctx.Size()
-	return canvasHeight * 0.28
+	return layout.Units(influenceHeightMM)
 }
 
 func getRunnerLimitsBottom(ctx *canvas.Context) float64 {
-	_, canvasHeight := ctx.Size()
-	return canvasHeight * 0.059994
+	return layout.Units(runnerLimitsBottomMM)
 }
 
 func getRunnerLimitsHeight(ctx *canvas.Context) float64 {
-	canvasWidth, _ := ctx.Size()
-	return canvasWidth * 0.08
+	return layout.Units(runnerLimitsHeightMM)
 }
 
 func (fb FrameBasic) drawCostCircle(ctx *canvas.Context, bgColor color.Color) {
@@ -91,7 +93,7 @@ func (fb FrameBasic) drawRezCost(ctx *canvas.Context, card *nrdb.Printing, fontS
 	if err != nil {
 		return nil, err
 	}
-	rezCostImage = rezCostImage.Transform(canvas.Identity.ReflectY()).Scale(0.1, 0.1).Scale(ScaleFactor, ScaleFactor)
+	rezCostImage = rezCostImage.Transform(canvas.Identity.ReflectY()).Scale(0.1, 0.1)
 
 	ctx.Push()
 	ctx.SetFillColor(fb.getColorBG())
@@ -132,7 +134,7 @@ func (fb FrameBasic) drawAgendaPoints(ctx *canvas.Context, card *nrdb.Printing,
 	if err != nil {
 		return nil, err
 	}
-	icon = icon.Transform(canvas.Identity.ReflectY()).Scale(0.07, 0.07).Scale(ScaleFactor, ScaleFactor)
+	icon = icon.Transform(canvas.Identity.ReflectY()).Scale(0.07, 0.07)
 
 	iconX := canvasWidth * 0.085
 	iconY := fb.getTextBoxHeight(ctx) + icon.Bounds().H*1.8
@@ -240,7 +242,7 @@ func (fb FrameBasic) drawMU(ctx *canvas.Context, card *nrdb.Printing, drawBox bo
 	if err != nil {
 		panic(err)
 	}
-	muImage = muImage.Transform(canvas.Identity.ReflectY()).Scale(0.05, 0.05).Scale(ScaleFactor, ScaleFactor)
+	muImage = muImage.Transform(canvas.Identity.ReflectY()).Scale(0.05, 0.05)
 
 	muBoxX := canvasWidth * 0.0853
 	muBoxY := (getTitleBoxTop(ctx) - getTitleBoxHeight(ctx)) - (muImage.Bounds().H * 0.8)
@@ -307,7 +309,7 @@ func (fb FrameBasic) drawLink(ctx *canvas.Context, card *nrdb.Printing) {
 	if err != nil {
 		panic(err)
 	}
-	icon = icon.Transform(canvas.Identity.ReflectY()).Scale(0.015, 0.015).Scale(ScaleFactor, ScaleFactor)
+	icon = icon.Transform(canvas.Identity.ReflectY()).Scale(0.015, 0.015)
 
 	boxX := canvasWidth * 0.1
 	boxY := getTitleBoxTop(ctx) - getTitleBoxHeight(ctx)*0.6
@@ -9,8 +9,11 @@ require (
 	github.com/mangofeet/nrdb-go v0.2.0
 	github.com/ojrac/opensimplex-go v1.0.2
 	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
 	github.com/tdewolff/canvas v0.0.0-20240420213651-d5a04e36ef50
+	golang.org/x/image v0.15.0
 	golang.org/x/text v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -24,12 +27,10 @@ require (
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/tdewolff/font v0.0.0-20240417221047-e5855237f87b // indirect
 	github.com/tdewolff/minify/v2 v2.20.5 // indirect
 	github.com/tdewolff/parse/v2 v2.7.3 // indirect
 	github.com/wcharczuk/go-chart/v2 v2.1.1 // indirect
-	golang.org/x/image v0.15.0 // indirect
 	golang.org/x/net v0.24.0 // indirect
 	gonum.org/v1/plot v0.14.0 // indirect
 	star-tex.org/x/tex v0.4.0 // indirect
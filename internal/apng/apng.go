@@ -0,0 +1,125 @@
+// Package apng puts PNG images together into an animated PNG.
+package apng
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Frame is one PNG encoded image and how long it is shown
+type Frame struct {
+	PNG   []byte
+	Delay time.Duration
+}
+
+type chunk struct {
+	kind string
+	data []byte
+}
+
+// Write writes the frames as an endlessly looping animation. Every
+// frame must have the same size and color type.
+func Write(w io.Writer, frames []Frame) error {
+	if len(frames) == 0 {
+		return errors.New("no frames to animate")
+	}
+
+	var header []byte
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+
+	sequence := uint32(0)
+	for i, frame := range frames {
+		chunks, err := readChunks(frame.PNG)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", i+1, err)
+		}
+
+		if i == 0 {
+			header = chunks[0].data
+			writeChunk(&buf, "IHDR", header)
+
+			acTL := make([]byte, 8)
+			binary.BigEndian.PutUint32(acTL[0:], uint32(len(frames)))
+			// no limit on the number of plays
+			binary.BigEndian.PutUint32(acTL[4:], 0)
+			writeChunk(&buf, "acTL", acTL)
+		} else if !bytes.Equal(chunks[0].data, header) {
+			return fmt.Errorf("frame %d: size or color type differs from the first frame", i+1)
+		}
+
+		// the header holds the width and height in its first 8 bytes
+		fcTL := make([]byte, 26)
+		binary.BigEndian.PutUint32(fcTL[0:], sequence)
+		copy(fcTL[4:12], header[:8])
+		binary.BigEndian.PutUint16(fcTL[20:], uint16(min(frame.Delay.Milliseconds(), 0xffff)))
+		binary.BigEndian.PutUint16(fcTL[22:], 1000)
+		// dispose and blend ops are left at 0, every frame replaces
+		// the whole image
+		writeChunk(&buf, "fcTL", fcTL)
+		sequence++
+
+		for _, c := range chunks {
+			if c.kind != "IDAT" {
+				continue
+			}
+			// the first frame doubles as the still image
+			if i == 0 {
+				writeChunk(&buf, "IDAT", c.data)
+				continue
+			}
+			fdAT := make([]byte, 4, 4+len(c.data))
+			binary.BigEndian.PutUint32(fdAT, sequence)
+			writeChunk(&buf, "fdAT", append(fdAT, c.data...))
+			sequence++
+		}
+	}
+
+	writeChunk(&buf, "IEND", nil)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// readChunks splits a PNG file into its chunks, the header first
+func readChunks(data []byte) ([]chunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []chunk
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data))
+		if len(data) < 12+length {
+			break
+		}
+		chunks = append(chunks, chunk{
+			kind: string(data[4:8]),
+			data: data[8 : 8+length],
+		})
+		data = data[12+length:]
+	}
+
+	if len(chunks) == 0 || chunks[0].kind != "IHDR" || len(chunks[0].data) < 8 {
+		return nil, errors.New("truncated PNG file")
+	}
+	return chunks, nil
+}
+
+func writeChunk(w *bytes.Buffer, kind string, data []byte) {
+	binary.Write(w, binary.BigEndian, uint32(len(data)))
+	w.WriteString(kind)
+	w.Write(data)
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	binary.Write(w, binary.BigEndian, crc.Sum32())
+}
@@ -0,0 +1,99 @@
+// Package deck reads deck lists as exported or pasted from the common
+// deck builders.
+package deck
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Entry is a card line of a deck list
+type Entry struct {
+	// Line is the line number in the list, 1 based
+	Line int
+	Name string
+	// Quantity is 1 when Counted is false
+	Quantity int
+	// Counted is set when the line gave a quantity. Lines without
+	// one are the deck name, section headers or the identity, so
+	// they are only used when they name an identity.
+	Counted bool
+}
+
+var (
+	// "3x Sure Gamble", "3 Sure Gamble", "3 x Sure Gamble"
+	leadingQuantity = regexp.MustCompile(`^(\d+)\s*[xX×]?\s+(.+)$`)
+	// "Sure Gamble x3"
+	trailingQuantity = regexp.MustCompile(`^(.+?)\s+[xX×](\d+)$`)
+	// "(System Gateway)" after a card name, and NRDB influence pips
+	trailingDetail = regexp.MustCompile(`(\s*\([^()]*\)|\s*[●•○◦·]+)+\s*$`)
+	// totals written below the cards by NRDB
+	summaryLine = regexp.MustCompile(`(?i)^\d+\s+(influence spent|agenda points|cards)\b`)
+)
+
+// sections are the headers NRDB groups cards under
+var sections = map[string]bool{
+	"agenda": true, "asset": true, "event": true, "hardware": true,
+	"ice": true, "identity": true, "operation": true, "program": true,
+	"resource": true, "upgrade": true,
+	// plural forms used by other builders
+	"agendas": true, "assets": true, "events": true, "operations": true,
+	"programs": true, "resources": true, "upgrades": true,
+}
+
+// Parse reads an NRDB plain text export, a jinteki.net paste or a
+// plain list of "3x Card Name" lines
+func Parse(r io.Reader) ([]Entry, error) {
+
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		if summaryLine.MatchString(line) ||
+			strings.HasPrefix(line, "Cards up to ") ||
+			strings.HasPrefix(line, "Deck built on ") {
+			continue
+		}
+
+		entry := Entry{
+			Line:     lineNum,
+			Quantity: 1,
+		}
+
+		if match := leadingQuantity.FindStringSubmatch(line); match != nil {
+			entry.Quantity, _ = strconv.Atoi(match[1])
+			entry.Name = match[2]
+			entry.Counted = true
+		} else if match := trailingQuantity.FindStringSubmatch(line); match != nil {
+			entry.Quantity, _ = strconv.Atoi(match[2])
+			entry.Name = match[1]
+			entry.Counted = true
+		} else {
+			entry.Name = line
+		}
+
+		entry.Name = strings.TrimSpace(trailingDetail.ReplaceAllString(entry.Name, ""))
+		if entry.Name == "" || entry.Quantity < 1 {
+			continue
+		}
+
+		if !entry.Counted && sections[strings.ToLower(entry.Name)] {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
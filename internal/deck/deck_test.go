@@ -0,0 +1,97 @@
+package deck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Entry
+	}{
+		{
+			name:  "leading quantity",
+			input: "3x Sure Gamble\n2 Dirty Laundry\n1 x Diesel\n2× Career Fair",
+			want: []Entry{
+				{Line: 1, Name: "Sure Gamble", Quantity: 3, Counted: true},
+				{Line: 2, Name: "Dirty Laundry", Quantity: 2, Counted: true},
+				{Line: 3, Name: "Diesel", Quantity: 1, Counted: true},
+				{Line: 4, Name: "Career Fair", Quantity: 2, Counted: true},
+			},
+		},
+		{
+			name:  "trailing quantity",
+			input: "Sure Gamble x3\nDiesel X2",
+			want: []Entry{
+				{Line: 1, Name: "Sure Gamble", Quantity: 3, Counted: true},
+				{Line: 2, Name: "Diesel", Quantity: 2, Counted: true},
+			},
+		},
+		{
+			name:  "set and influence pips",
+			input: "3x Sure Gamble (System Gateway)\n2x Account Siphon ●●●●● (Core Set)\n1x Boomerang ••",
+			want: []Entry{
+				{Line: 1, Name: "Sure Gamble", Quantity: 3, Counted: true},
+				{Line: 2, Name: "Account Siphon", Quantity: 2, Counted: true},
+				{Line: 3, Name: "Boomerang", Quantity: 1, Counted: true},
+			},
+		},
+		{
+			name: "nrdb export",
+			input: `Anarch Ramp
+
+Esâ Afontov: Eco-Insurrectionist (Midnight Sun)
+
+Event (2)
+2x Sure Gamble (System Gateway)
+
+Programs (1)
+1x Cleaver (System Gateway)
+
+15 influence spent (max 15, available 0)
+20 agenda points
+45 cards (min 45)
+Cards up to Liberation
+Deck built on https://netrunnerdb.com`,
+			want: []Entry{
+				{Line: 1, Name: "Anarch Ramp", Quantity: 1},
+				{Line: 3, Name: "Esâ Afontov: Eco-Insurrectionist", Quantity: 1},
+				{Line: 6, Name: "Sure Gamble", Quantity: 2, Counted: true},
+				{Line: 9, Name: "Cleaver", Quantity: 1, Counted: true},
+			},
+		},
+		{
+			name:  "comments and blank lines",
+			input: "# my deck\n\n// sideboard below\n   \n3x Sure Gamble\n",
+			want: []Entry{
+				{Line: 5, Name: "Sure Gamble", Quantity: 3, Counted: true},
+			},
+		},
+		{
+			name:  "zero quantity",
+			input: "0x Sure Gamble",
+			want:  nil,
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Package httpcache keeps API responses on disk so repeated runs
+// don't fetch the same data again.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotCached is returned in offline mode for requests that have no
+// cached response
+var ErrNotCached = errors.New("not in cache")
+
+// Cache is an http.RoundTripper storing successful GET responses in
+// a directory, keyed by request URL
+type Cache struct {
+	// Dir holds one file per cached response
+	Dir string
+	// TTL is how long a response is served from the cache, zero
+	// means forever
+	TTL time.Duration
+	// Offline serves only from the cache, regardless of TTL, and
+	// fails on a miss instead of making a request
+	Offline bool
+	// Transport makes the actual requests, defaults to
+	// http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Entry is a cached response
+type Entry struct {
+	URL      string      `json:"url"`
+	StoredAt time.Time   `json:"stored_at"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+
+	// Path is the file the entry is stored in
+	Path string `json:"-"`
+	// Size is the size of the file on disk
+	Size int64 `json:"-"`
+}
+
+func DefaultDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "netrunner-alt-gen", "http"), nil
+}
+
+func (cache *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	if req.Method != http.MethodGet {
+		if cache.Offline {
+			return nil, fmt.Errorf("%w (offline mode)", ErrNotCached)
+		}
+		return cache.transport().RoundTrip(req)
+	}
+
+	url := req.URL.String()
+
+	entry, err := cache.load(url)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if entry != nil && (cache.Offline || !cache.Expired(entry)) {
+		return entry.response(req), nil
+	}
+
+	if cache.Offline {
+		return nil, fmt.Errorf("%w (offline mode)", ErrNotCached)
+	}
+
+	res, err := cache.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := cache.store(&Entry{
+		URL:      url,
+		StoredAt: time.Now(),
+		Header:   res.Header,
+		Body:     body,
+	}); err != nil {
+		return nil, fmt.Errorf("caching response: %w", err)
+	}
+
+	return res, nil
+}
+
+func (cache *Cache) transport() http.RoundTripper {
+	if cache.Transport != nil {
+		return cache.Transport
+	}
+	return http.DefaultTransport
+}
+
+// Expired reports whether an entry is older than the TTL
+func (cache *Cache) Expired(entry *Entry) bool {
+	if cache.TTL <= 0 {
+		return false
+	}
+	return time.Since(entry.StoredAt) > cache.TTL
+}
+
+// Entries lists every cached response, oldest first
+func (cache *Cache) Entries() ([]*Entry, error) {
+
+	files, err := os.ReadDir(cache.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		entry, err := readEntry(filepath.Join(cache.Dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StoredAt.Before(entries[j].StoredAt)
+	})
+
+	return entries, nil
+}
+
+// Prune removes expired entries, or every entry when all is set, and
+// returns how many were removed
+func (cache *Cache) Prune(all bool) (int, error) {
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !all && !cache.Expired(entry) {
+			continue
+		}
+		if err := os.Remove(entry.Path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+func (cache *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(cache.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (cache *Cache) load(url string) (*Entry, error) {
+	return readEntry(cache.path(url))
+}
+
+func (cache *Cache) store(entry *Entry) error {
+
+	if err := os.MkdirAll(cache.Dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a concurrent reader never
+	// sees a partial entry
+	tmp, err := os.CreateTemp(cache.Dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), cache.path(entry.URL))
+}
+
+func readEntry(path string) (*Entry, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("reading cache entry %s: %w", path, err)
+	}
+	entry.Path = path
+	entry.Size = int64(len(data))
+
+	return &entry, nil
+}
+
+func (entry *Entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
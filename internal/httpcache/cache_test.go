@@ -0,0 +1,147 @@
+package httpcache
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTransport answers every request with the body for its path and
+// counts the requests that reached it
+type fakeTransport struct {
+	bodies   map[string]string
+	requests int
+}
+
+func (tr *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr.requests++
+	body, ok := tr.bodies[req.URL.Path]
+	status := http.StatusOK
+	if !ok {
+		status, body = http.StatusNotFound, "not found"
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, string, error) {
+	t.Helper()
+	res, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(body), nil
+}
+
+func TestCacheServesRepeatedRequests(t *testing.T) {
+
+	tr := &fakeTransport{bodies: map[string]string{"/printings/01050": "hedge fund"}}
+	cache := &Cache{Dir: t.TempDir(), Transport: tr}
+	client := &http.Client{Transport: cache}
+
+	for i := 0; i < 3; i++ {
+		status, body, err := get(t, client, "https://api.example.com/printings/01050")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusOK || body != "hedge fund" {
+			t.Fatalf("request %d: got %d %q", i, status, body)
+		}
+	}
+	if tr.requests != 1 {
+		t.Errorf("got %d requests, want 1", tr.requests)
+	}
+
+	// a missing printing isn't cached, so it's asked for every time
+	for i := 0; i < 2; i++ {
+		if status, _, _ := get(t, client, "https://api.example.com/printings/99999"); status != http.StatusNotFound {
+			t.Fatalf("got %d for a missing printing", status)
+		}
+	}
+	if tr.requests != 3 {
+		t.Errorf("got %d requests, want 3", tr.requests)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+
+	tr := &fakeTransport{bodies: map[string]string{"/printings/01050": "hedge fund"}}
+	cache := &Cache{Dir: t.TempDir(), Transport: tr, TTL: time.Hour}
+	client := &http.Client{Transport: cache}
+
+	if _, _, err := get(t, client, "https://api.example.com/printings/01050"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := cache.Entries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("got %d entries, %v", len(entries), err)
+	}
+	if cache.Expired(entries[0]) {
+		t.Error("a fresh entry is expired")
+	}
+
+	// age the entry past the TTL
+	entries[0].StoredAt = time.Now().Add(-2 * time.Hour)
+	if err := cache.store(entries[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed, err := cache.Prune(false); err != nil || removed != 1 {
+		t.Fatalf("pruned %d entries, %v, want 1", removed, err)
+	}
+	if _, _, err := get(t, client, "https://api.example.com/printings/01050"); err != nil {
+		t.Fatal(err)
+	}
+	if tr.requests != 2 {
+		t.Errorf("got %d requests, want the expired entry fetched again", tr.requests)
+	}
+
+	if removed, err := cache.Prune(true); err != nil || removed != 1 {
+		t.Errorf("pruned %d entries, %v, want 1", removed, err)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+
+	dir := t.TempDir()
+	tr := &fakeTransport{bodies: map[string]string{"/printings/01050": "hedge fund"}}
+
+	online := &http.Client{Transport: &Cache{Dir: dir, Transport: tr}}
+	if _, _, err := get(t, online, "https://api.example.com/printings/01050"); err != nil {
+		t.Fatal(err)
+	}
+
+	// offline ignores the TTL and never makes a request
+	offline := &http.Client{Transport: &Cache{Dir: dir, Transport: tr, TTL: time.Nanosecond, Offline: true}}
+	time.Sleep(time.Millisecond)
+
+	_, body, err := get(t, offline, "https://api.example.com/printings/01050")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "hedge fund" {
+		t.Errorf("got %q offline", body)
+	}
+
+	_, _, err = get(t, offline, "https://api.example.com/printings/01051")
+	if !errors.Is(err, ErrNotCached) {
+		t.Errorf("got %v for a miss offline, want %v", err, ErrNotCached)
+	}
+
+	if tr.requests != 1 {
+		t.Errorf("got %d requests, want only the one made online", tr.requests)
+	}
+}
@@ -0,0 +1,245 @@
+// Package metadata stores text in the files the generator writes: PNG
+// text chunks, an SVG metadata element or the PDF document info.
+package metadata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned when a file has no text under the key
+var ErrNotFound = errors.New("no metadata found")
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Entry is one piece of text stored under a keyword
+type Entry struct {
+	Key, Value string
+}
+
+// Read returns the text stored under key in a PNG, SVG or PDF file
+func Read(path, key string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".png":
+		text, err := pngText(data)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		value = text[key]
+	case ".svg":
+		value, err = svgText(data, key)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+	case ".pdf":
+		value, err = pdfText(data, key)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return "", fmt.Errorf("%s: can't read metadata from %s files", path, ext)
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+	return value, nil
+}
+
+// PNGChunks encodes entries as PNG text chunks, to go right after the
+// header. ASCII values are written as tEXt chunks, anything else as
+// iTXt.
+func PNGChunks(entries ...Entry) []byte {
+	var chunks bytes.Buffer
+	for _, entry := range entries {
+		if isASCII(entry.Value) {
+			writePNGChunk(&chunks, "tEXt", []byte(entry.Key+"\x00"+entry.Value))
+			continue
+		}
+		// keyword, no compression, empty language and translated keyword
+		writePNGChunk(&chunks, "iTXt", []byte(entry.Key+"\x00\x00\x00\x00\x00"+entry.Value))
+	}
+	return chunks.Bytes()
+}
+
+func writePNGChunk(w *bytes.Buffer, kind string, data []byte) {
+	binary.Write(w, binary.BigEndian, uint32(len(data)))
+	w.WriteString(kind)
+	w.Write(data)
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+// pngText reads every tEXt and iTXt chunk
+func pngText(data []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+
+	text := map[string]string{}
+
+	r := bytes.NewReader(data[len(pngSignature):])
+	for {
+		var length uint32
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return nil, errors.New("truncated PNG file")
+		}
+		kind := make([]byte, 4)
+		chunk := make([]byte, length)
+		if _, err := io.ReadFull(r, kind); err != nil {
+			return nil, errors.New("truncated PNG file")
+		}
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, errors.New("truncated PNG file")
+		}
+		if _, err := r.Seek(4, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+
+		switch string(kind) {
+		case "tEXt":
+			key, value, _ := bytes.Cut(chunk, []byte{0})
+			text[string(key)] = string(value)
+		case "iTXt":
+			key, value, err := parseITXt(chunk)
+			if err != nil {
+				return nil, err
+			}
+			text[key] = value
+		case "IEND":
+			return text, nil
+		}
+	}
+}
+
+func parseITXt(chunk []byte) (string, string, error) {
+	key, rest, _ := bytes.Cut(chunk, []byte{0})
+	if len(rest) < 2 {
+		return "", "", errors.New("invalid iTXt chunk")
+	}
+	compressed := rest[0] == 1
+	rest = rest[2:]
+	// skip the language tag and translated keyword
+	_, rest, _ = bytes.Cut(rest, []byte{0})
+	_, rest, _ = bytes.Cut(rest, []byte{0})
+
+	if !compressed {
+		return string(key), string(rest), nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(rest))
+	if err != nil {
+		return "", "", err
+	}
+	value, err := io.ReadAll(zr)
+	if err != nil {
+		return "", "", err
+	}
+	return string(key), string(value), nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// AddSVG adds a metadata element with the given ID to an SVG file
+func AddSVG(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	start := bytes.Index(data, []byte("<svg"))
+	if start == -1 {
+		return fmt.Errorf("%s: not an SVG file", path)
+	}
+	end := bytes.IndexByte(data[start:], '>')
+	if end == -1 {
+		return fmt.Errorf("%s: not an SVG file", path)
+	}
+	end += start + 1
+
+	var element bytes.Buffer
+	fmt.Fprintf(&element, `<metadata id="%s">`, key)
+	if err := xml.EscapeText(&element, []byte(value)); err != nil {
+		return err
+	}
+	element.WriteString("</metadata>")
+
+	out := make([]byte, 0, len(data)+element.Len())
+	out = append(out, data[:end]...)
+	out = append(out, element.Bytes()...)
+	out = append(out, data[end:]...)
+
+	return os.WriteFile(path, out, 0644)
+}
+
+func svgText(data []byte, key string) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "metadata" {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "id" && attr.Value == key {
+				var value string
+				if err := dec.DecodeElement(&value, &start); err != nil {
+					return "", err
+				}
+				return value, nil
+			}
+		}
+	}
+}
+
+// PDFKeywords encodes a value for the keywords of a PDF's document
+// info, the PDF writer only handles plain text there
+func PDFKeywords(key, value string) string {
+	return key + ":" + base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+func pdfText(data []byte, key string) (string, error) {
+	re := regexp.MustCompile(regexp.QuoteMeta(key) + `:([A-Za-z0-9+/=]+)`)
+	match := re.FindSubmatch(data)
+	if match == nil {
+		return "", nil
+	}
+	value, err := base64.StdEncoding.DecodeString(string(match[1]))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
@@ -0,0 +1,121 @@
+package metadata
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mangofeet/netrunner-alt-gen/internal/pngstream"
+)
+
+// the parameter record is JSON, and card titles aren't always ASCII
+const (
+	asciiValue   = `{"printing_id":"01050","title":"Hedge Fund"}`
+	unicodeValue = `{"printing_id":"26010","title":"Café Society ◆"}`
+)
+
+// writePNG writes a small image with entries the way cards are written
+func writePNG(t *testing.T, path string, entries ...Entry) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	enc, err := pngstream.NewEncoder(f, 4, 4, PNGChunks(entries...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteRows(image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPNGRoundTrip(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "card.png")
+	writePNG(t, path, Entry{Key: "ascii", Value: asciiValue}, Entry{Key: "unicode", Value: unicodeValue})
+
+	for key, want := range map[string]string{"ascii": asciiValue, "unicode": unicodeValue} {
+		got, err := Read(path, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %s for %s, want %s", got, key, want)
+		}
+	}
+
+	// the image itself is still readable
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Errorf("decoding the PNG with metadata: %s", err)
+	}
+
+	if _, err := Read(path, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v for a missing key, want %v", err, ErrNotFound)
+	}
+}
+
+func TestSVGRoundTrip(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "card.svg")
+	svg := `<?xml version="1.0" encoding="UTF-8"?><svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect width="10" height="10"/></svg>`
+	if err := os.WriteFile(path, []byte(svg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddSVG(path, "params", unicodeValue+` <&>`); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(path, "params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != unicodeValue+` <&>` {
+		t.Errorf("got %s", got)
+	}
+
+	if _, err := Read(path, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v for a missing key, want %v", err, ErrNotFound)
+	}
+}
+
+func TestPDFRoundTrip(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "card.pdf")
+	pdf := "%PDF-1.7\n1 0 obj\n<< /Keywords (" + PDFKeywords("params", unicodeValue) + ") >>\nendobj\n%%EOF\n"
+	if err := os.WriteFile(path, []byte(pdf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(path, "params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != unicodeValue {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestReadUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "card.jpg")
+	if err := os.WriteFile(path, []byte("not a png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path, "params"); err == nil {
+		t.Error("expected an error for a jpg")
+	}
+}
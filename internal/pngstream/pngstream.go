@@ -0,0 +1,235 @@
+// Package pngstream writes a PNG image a band of rows at a time, so the
+// whole image never has to be in memory.
+package pngstream
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// PNG row filters
+const (
+	filterNone = iota
+	filterSub
+	filterUp
+	filterAverage
+	filterPaeth
+)
+
+// Encoder writes an 8 bit RGBA PNG
+type Encoder struct {
+	w             io.Writer
+	width, height int
+	written       int
+
+	idat *bufio.Writer
+	zw   *zlib.Writer
+
+	// the previous row and the current one under every filter, each
+	// starting with its filter type
+	prev     []byte
+	filtered [5][]byte
+}
+
+// NewEncoder writes the PNG header for an image of the given size to w,
+// followed by chunks, which are already encoded chunks to put before
+// the image data
+func NewEncoder(w io.Writer, width, height int, chunks []byte) (*Encoder, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+
+	enc := &Encoder{
+		w:      w,
+		width:  width,
+		height: height,
+		prev:   make([]byte, 1+width*4),
+	}
+	for i := range enc.filtered {
+		enc.filtered[i] = make([]byte, 1+width*4)
+		enc.filtered[i][0] = byte(i)
+	}
+
+	if _, err := w.Write(pngSignature); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], uint32(width))
+	binary.BigEndian.PutUint32(header[4:], uint32(height))
+	// 8 bits, RGBA, deflate, adaptive filtering, not interlaced
+	header[8] = 8
+	header[9] = 6
+	if err := writeChunk(w, "IHDR", header); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(chunks); err != nil {
+		return nil, err
+	}
+
+	// every buffer full of compressed data is an IDAT chunk
+	enc.idat = bufio.NewWriterSize(chunkWriter{w}, 1<<15)
+	enc.zw = zlib.NewWriter(enc.idat)
+
+	return enc, nil
+}
+
+// WriteRows writes the next rows of the image, img has to be as wide as
+// the image
+func (enc *Encoder) WriteRows(img *image.RGBA) error {
+	bounds := img.Bounds()
+	if bounds.Dx() != enc.width {
+		return fmt.Errorf("rows are %d pixels wide, the image is %d", bounds.Dx(), enc.width)
+	}
+	if enc.written+bounds.Dy() > enc.height {
+		return fmt.Errorf("too many rows, the image is %d high", enc.height)
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(bounds.Min.X, y):img.PixOffset(bounds.Max.X, y)]
+		unpremultiply(enc.filtered[filterNone][1:], row)
+
+		best := enc.filter()
+		if _, err := enc.zw.Write(enc.filtered[best]); err != nil {
+			return err
+		}
+
+		copy(enc.prev, enc.filtered[filterNone])
+		enc.written++
+	}
+
+	return nil
+}
+
+// Close finishes the image data, every row has to have been written
+func (enc *Encoder) Close() error {
+	if enc.written != enc.height {
+		return fmt.Errorf("only %d of %d rows were written", enc.written, enc.height)
+	}
+	if err := enc.zw.Close(); err != nil {
+		return err
+	}
+	if err := enc.idat.Flush(); err != nil {
+		return err
+	}
+	return writeChunk(enc.w, "IEND", nil)
+}
+
+// unpremultiply copies RGBA pixels as PNG stores them, with their color
+// not multiplied by their alpha, the way image/png does it
+func unpremultiply(dst, src []byte) {
+	for ; len(src) >= 4; dst, src = dst[4:], src[4:] {
+		switch a := src[3]; a {
+		case 0x00:
+			dst[0], dst[1], dst[2], dst[3] = 0, 0, 0, 0
+		case 0xff:
+			copy(dst[:4], src[:4])
+		default:
+			const m = 0x101 * 0xffff
+			a := uint32(a) * 0x101
+			dst[0] = uint8((uint32(src[0]) * m / a) >> 8)
+			dst[1] = uint8((uint32(src[1]) * m / a) >> 8)
+			dst[2] = uint8((uint32(src[2]) * m / a) >> 8)
+			dst[3] = src[3]
+		}
+	}
+}
+
+// filter fills in the current row under every filter and picks the one
+// with the smallest sum of differences, the heuristic image/png uses
+func (enc *Encoder) filter() int {
+	const bpp = 4
+
+	cur := enc.filtered[filterNone][1:]
+	prev := enc.prev[1:]
+	sub := enc.filtered[filterSub][1:]
+	up := enc.filtered[filterUp][1:]
+	average := enc.filtered[filterAverage][1:]
+	paeth := enc.filtered[filterPaeth][1:]
+
+	for i := range cur {
+		var left, upLeft byte
+		if i >= bpp {
+			left, upLeft = cur[i-bpp], prev[i-bpp]
+		}
+		sub[i] = cur[i] - left
+		up[i] = cur[i] - prev[i]
+		average[i] = cur[i] - byte((int(left)+int(prev[i]))/2)
+		paeth[i] = cur[i] - paethPredictor(left, prev[i], upLeft)
+	}
+
+	best, bestSum := filterNone, -1
+	for f, row := range enc.filtered {
+		sum := 0
+		for _, b := range row[1:] {
+			sum += abs8(b)
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = f, sum
+		}
+	}
+	return best
+}
+
+func paethPredictor(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+// abs8 is the size of a filtered byte read as a signed difference
+func abs8(b byte) int {
+	if b < 128 {
+		return int(b)
+	}
+	return 256 - int(b)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// chunkWriter writes everything it's given as an IDAT chunk
+type chunkWriter struct {
+	w io.Writer
+}
+
+func (cw chunkWriter) Write(data []byte) (int, error) {
+	if err := writeChunk(cw.w, "IDAT", data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func writeChunk(w io.Writer, kind string, data []byte) error {
+	if len(data) > 0x7fffffff {
+		return errors.New("chunk too big")
+	}
+
+	buf := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], kind)
+	buf = append(buf, data...)
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+
+	_, err := w.Write(buf)
+	return err
+}
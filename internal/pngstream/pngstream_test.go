@@ -0,0 +1,99 @@
+package pngstream
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+// testImage has gradients for the row filters to work on, noise and
+// translucent pixels to unpremultiply
+func testImage(width, height int) *image.RGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			a := uint8(255)
+			if (x+y)%3 == 0 {
+				a = uint8(rng.Intn(256))
+			}
+			c := color.NRGBA{R: uint8(x * 7), G: uint8(y * 11), B: uint8(rng.Intn(256)), A: a}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestEncoderMatchesImagePNG(t *testing.T) {
+
+	img := testImage(37, 23)
+
+	var want bytes.Buffer
+	if err := png.Encode(&want, img); err != nil {
+		t.Fatal(err)
+	}
+	wantImg := decode(t, want.Bytes())
+
+	// bands that don't divide the height, down to single rows
+	for _, band := range []int{1, 5, 23} {
+		var buf bytes.Buffer
+		enc, err := NewEncoder(&buf, 37, 23, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 23; y += band {
+			rows := img.SubImage(image.Rect(0, y, 37, min(y+band, 23))).(*image.RGBA)
+			if err := enc.WriteRows(rows); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got := decode(t, buf.Bytes())
+		for y := 0; y < 23; y++ {
+			for x := 0; x < 37; x++ {
+				if got.At(x, y) != wantImg.At(x, y) {
+					t.Fatalf("bands of %d: pixel %d,%d is %v, want %v", band, x, y, got.At(x, y), wantImg.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestEncoderErrors(t *testing.T) {
+
+	if _, err := NewEncoder(&bytes.Buffer{}, 0, 10, nil); err == nil {
+		t.Error("expected an error for an empty image")
+	}
+
+	enc, err := NewEncoder(&bytes.Buffer{}, 4, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteRows(image.NewRGBA(image.Rect(0, 0, 3, 1))); err == nil {
+		t.Error("expected an error for rows of the wrong width")
+	}
+	if err := enc.WriteRows(image.NewRGBA(image.Rect(0, 0, 4, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err == nil {
+		t.Error("expected an error closing with a row missing")
+	}
+	if err := enc.WriteRows(image.NewRGBA(image.Rect(0, 0, 4, 2))); err == nil {
+		t.Error("expected an error for too many rows")
+	}
+}
@@ -0,0 +1,374 @@
+// Package query implements a small filter language over printings,
+// modelled on the NRDB search syntax.
+//
+// A query is a list of terms that all have to match, e.g.
+//
+//	faction:jinteki type:ice strength>=4
+//	f:shaper t:program s:icebreaker
+//	text:"end the run" cost<=2
+//
+// Each term is a field, an operator and a value. The operators are
+// ":" and "=" for equality, "!" for inequality and "<", "<=", ">",
+// ">=" for numeric fields. Several values can be given separated by
+// "|", e.g. f:anarch|criminal. Values with spaces are quoted.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+type fieldKind int
+
+const (
+	kindID fieldKind = iota
+	kindText
+	kindNumber
+)
+
+type field struct {
+	kind fieldKind
+	// text returns the values a text or ID field is compared to, for
+	// numeric fields it returns the raw value to match X against
+	text func(attrs *nrdb.PrintingAttributes) []string
+	// number returns the value of a numeric field, ok is false when
+	// the card doesn't have it
+	number func(attrs *nrdb.PrintingAttributes) (value int, ok bool)
+}
+
+var fields = map[string]field{
+	"faction": {kind: kindID, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return []string{attrs.FactionID}
+	}},
+	"type": {kind: kindID, text: func(attrs *nrdb.PrintingAttributes) []string {
+		types := []string{attrs.CardTypeID}
+		if strings.HasSuffix(attrs.CardTypeID, "_identity") {
+			types = append(types, "identity")
+		}
+		return types
+	}},
+	"side": {kind: kindID, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return []string{attrs.SideID}
+	}},
+	"set": {kind: kindID, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return []string{attrs.CardSetID}
+	}},
+	"cycle": {kind: kindID, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return []string{attrs.CardCycleID}
+	}},
+	"subtype": {kind: kindID, text: subtypes},
+	"title": {kind: kindText, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return []string{attrs.Title, attrs.StrippedTitle}
+	}},
+	"text": {kind: kindText, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return []string{attrs.Text, attrs.StrippedText}
+	}},
+	"cost": {kind: kindNumber, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return raw(attrs.Cost)
+	}, number: func(attrs *nrdb.PrintingAttributes) (int, bool) {
+		return atoi(attrs.Cost)
+	}},
+	"advancement": {kind: kindNumber, text: func(attrs *nrdb.PrintingAttributes) []string {
+		return raw(attrs.AdvancementRequirement)
+	}, number: func(attrs *nrdb.PrintingAttributes) (int, bool) {
+		return atoi(attrs.AdvancementRequirement)
+	}},
+	"strength":  {kind: kindNumber, number: intField(func(attrs *nrdb.PrintingAttributes) *int { return attrs.Strength })},
+	"influence": {kind: kindNumber, number: intField(func(attrs *nrdb.PrintingAttributes) *int { return attrs.InfluenceCost })},
+	"memory":    {kind: kindNumber, number: intField(func(attrs *nrdb.PrintingAttributes) *int { return attrs.MemoryCost })},
+	"trash":     {kind: kindNumber, number: intField(func(attrs *nrdb.PrintingAttributes) *int { return attrs.TrashCost })},
+	"points":    {kind: kindNumber, number: intField(func(attrs *nrdb.PrintingAttributes) *int { return attrs.AgendaPoints })},
+	"link":      {kind: kindNumber, number: intField(func(attrs *nrdb.PrintingAttributes) *int { return attrs.BaseLink })},
+}
+
+// aliases are the NRDB search letters and other names for fields
+var aliases = map[string]string{
+	"f":        "faction",
+	"t":        "type",
+	"d":        "side",
+	"e":        "set",
+	"c":        "cycle",
+	"s":        "subtype",
+	"subtypes": "subtype",
+	"_":        "title",
+	"name":     "title",
+	"x":        "text",
+	"o":        "cost",
+	"g":        "advancement",
+	"p":        "strength",
+	"n":        "influence",
+	"m":        "memory",
+	"h":        "trash",
+	"v":        "points",
+	"l":        "link",
+}
+
+var operators = []string{"<=", ">=", "!=", ":", "=", "!", "<", ">"}
+
+type term struct {
+	field  field
+	op     string
+	values []string
+}
+
+// Query is a parsed query, the zero value matches every printing
+type Query struct {
+	terms []term
+}
+
+// Parse reads a query, reporting unknown fields and values that
+// don't fit their field
+func Parse(input string) (*Query, error) {
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+	for _, token := range tokens {
+		t, err := parseTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		q.terms = append(q.terms, t)
+	}
+
+	return q, nil
+}
+
+func parseTerm(token string) (term, error) {
+
+	opIndex, op := -1, ""
+	for i := range token {
+		for _, candidate := range operators {
+			if strings.HasPrefix(token[i:], candidate) {
+				opIndex, op = i, candidate
+				break
+			}
+		}
+		if opIndex >= 0 {
+			break
+		}
+	}
+
+	if opIndex <= 0 {
+		// a bare word searches titles, like on NRDB
+		return term{field: fields["title"], op: ":", values: []string{token}}, nil
+	}
+
+	name := strings.ToLower(token[:opIndex])
+	value := token[opIndex+len(op):]
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+
+	f, ok := fields[name]
+	if !ok {
+		return term{}, fmt.Errorf(`unknown field "%s" in "%s"`, token[:opIndex], token)
+	}
+
+	switch op {
+	case "=":
+		op = ":"
+	case "!=":
+		op = "!"
+	}
+
+	if value == "" {
+		return term{}, fmt.Errorf(`no value in "%s"`, token)
+	}
+
+	t := term{field: f, op: op, values: strings.Split(value, "|")}
+
+	if f.kind != kindNumber && op != ":" && op != "!" {
+		return term{}, fmt.Errorf(`"%s" can't be used with %s, only ":" and "!"`, op, name)
+	}
+
+	if f.kind == kindNumber {
+		for _, value := range t.values {
+			if strings.EqualFold(value, "x") && f.text != nil && (op == ":" || op == "!") {
+				continue
+			}
+			if _, err := strconv.Atoi(value); err != nil {
+				return term{}, fmt.Errorf(`%s must be a number, got "%s"`, name, value)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// tokenize splits on spaces outside of quotes and removes the quotes
+func tokenize(input string) ([]string, error) {
+
+	var tokens []string
+	var current strings.Builder
+	inQuote := false
+	hasToken := false
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			hasToken = true
+		case unicode.IsSpace(r) && !inQuote:
+			if hasToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				hasToken = false
+			}
+		default:
+			current.WriteRune(r)
+			hasToken = true
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("unclosed quote in query")
+	}
+	if hasToken {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens, nil
+}
+
+// Match reports whether a printing matches every term
+func (q *Query) Match(printing *nrdb.Printing) bool {
+	if printing == nil || printing.Attributes == nil {
+		return false
+	}
+
+	for _, t := range q.terms {
+		if !t.match(printing.Attributes) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Filter returns the printings matching the query
+func (q *Query) Filter(printings []*nrdb.Printing) []*nrdb.Printing {
+	var matched []*nrdb.Printing
+	for _, printing := range printings {
+		if q.Match(printing) {
+			matched = append(matched, printing)
+		}
+	}
+	return matched
+}
+
+func (t term) match(attrs *nrdb.PrintingAttributes) bool {
+
+	matched := false
+	for _, value := range t.values {
+		if t.matchValue(attrs, value) {
+			matched = true
+			break
+		}
+	}
+
+	if t.op == "!" {
+		return !matched
+	}
+	return matched
+}
+
+func (t term) matchValue(attrs *nrdb.PrintingAttributes, value string) bool {
+
+	switch t.field.kind {
+	case kindID:
+		for _, candidate := range t.field.text(attrs) {
+			if normalize(candidate) == normalize(value) {
+				return true
+			}
+		}
+	case kindText:
+		for _, candidate := range t.field.text(attrs) {
+			if strings.Contains(strings.ToLower(candidate), strings.ToLower(value)) {
+				return true
+			}
+		}
+	case kindNumber:
+		if strings.EqualFold(value, "x") {
+			for _, candidate := range t.field.text(attrs) {
+				if strings.EqualFold(candidate, "x") {
+					return true
+				}
+			}
+			return false
+		}
+
+		cardValue, ok := t.field.number(attrs)
+		want, _ := strconv.Atoi(value)
+		if !ok {
+			return false
+		}
+		switch t.op {
+		case ":", "!":
+			return cardValue == want
+		case "<":
+			return cardValue < want
+		case "<=":
+			return cardValue <= want
+		case ">":
+			return cardValue > want
+		case ">=":
+			return cardValue >= want
+		}
+	}
+
+	return false
+}
+
+// normalize makes "Code Gate", "code_gate" and "code-gate" equal
+func normalize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '_' {
+			return '_'
+		}
+		return unicode.ToLower(r)
+	}, strings.TrimSpace(value))
+}
+
+func subtypes(attrs *nrdb.PrintingAttributes) []string {
+	values := append([]string{}, attrs.CardSubtypeIDs...)
+	values = append(values, attrs.CardSubtypeNames...)
+	if attrs.DisplaySubtypes != nil {
+		for _, subtype := range strings.Split(*attrs.DisplaySubtypes, " - ") {
+			values = append(values, strings.TrimSpace(subtype))
+		}
+	}
+	return values
+}
+
+func raw(value *string) []string {
+	if value == nil {
+		return nil
+	}
+	return []string{*value}
+}
+
+func atoi(value *string) (int, bool) {
+	if value == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(*value)
+	return n, err == nil
+}
+
+func intField(get func(attrs *nrdb.PrintingAttributes) *int) func(attrs *nrdb.PrintingAttributes) (int, bool) {
+	return func(attrs *nrdb.PrintingAttributes) (int, bool) {
+		value := get(attrs)
+		if value == nil {
+			return 0, false
+		}
+		return *value, true
+	}
+}
@@ -0,0 +1,140 @@
+package query
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{input: ""},
+		{input: "hedge fund"},
+		{input: "faction:jinteki type:ice strength>=4"},
+		{input: "f:shaper t:program s:icebreaker"},
+		{input: `text:"end the run" cost<=2`},
+		{input: "f:anarch|criminal"},
+		{input: "cost:x"},
+		{input: "cost!=x"},
+		{input: "colour:red", wantErr: true},
+		{input: "cost:", wantErr: true},
+		{input: "faction>3", wantErr: true},
+		{input: "cost>cheap", wantErr: true},
+		{input: "cost<x", wantErr: true},
+		{input: "strength:x", wantErr: true},
+		{input: `text:"end the run`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if tt.wantErr && err == nil {
+				t.Fatal("expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func card(title string, edit func(attrs *nrdb.PrintingAttributes)) *nrdb.Printing {
+	attrs := &nrdb.PrintingAttributes{}
+	attrs.Title = title
+	attrs.StrippedTitle = title
+	edit(attrs)
+	return &nrdb.Printing{
+		Document: nrdb.Document[nrdb.PrintingAttributes, nrdb.PrintingRelationships]{
+			ID:         title,
+			Attributes: attrs,
+		},
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+var printings = []*nrdb.Printing{
+	card("Tollbooth", func(attrs *nrdb.PrintingAttributes) {
+		attrs.FactionID, attrs.SideID, attrs.CardTypeID = "nbn", "corp", "ice"
+		attrs.DisplaySubtypes = ptr("Code Gate")
+		attrs.Text = "When the Runner encounters this ice, they must pay 3[credit], if able. If they cannot, end the run.\n[subroutine] End the run."
+		attrs.Cost, attrs.Strength, attrs.InfluenceCost = ptr("8"), ptr(5), ptr(2)
+	}),
+	card("Ice Wall", func(attrs *nrdb.PrintingAttributes) {
+		attrs.FactionID, attrs.SideID, attrs.CardTypeID = "haas_bioroid", "corp", "ice"
+		attrs.CardSubtypeIDs = []string{"barrier"}
+		attrs.Text = "[subroutine] End the run."
+		attrs.Cost, attrs.Strength, attrs.InfluenceCost = ptr("1"), ptr(1), ptr(1)
+	}),
+	card("Cleaver", func(attrs *nrdb.PrintingAttributes) {
+		attrs.FactionID, attrs.SideID, attrs.CardTypeID = "anarch", "runner", "program"
+		attrs.CardSubtypeIDs = []string{"icebreaker", "fracter"}
+		attrs.Cost, attrs.Strength, attrs.MemoryCost, attrs.InfluenceCost = ptr("3"), ptr(3), ptr(1), ptr(2)
+		attrs.CardSetID = "system_gateway"
+	}),
+	card("Mutual Favor", func(attrs *nrdb.PrintingAttributes) {
+		attrs.FactionID, attrs.SideID, attrs.CardTypeID = "shaper", "runner", "event"
+		attrs.Cost = ptr("0")
+	}),
+	card("Diversion of Funds", func(attrs *nrdb.PrintingAttributes) {
+		attrs.FactionID, attrs.SideID, attrs.CardTypeID = "criminal", "runner", "event"
+		attrs.Cost = ptr("X")
+	}),
+	card("Zahya Sadeghi: Versatile Smuggler", func(attrs *nrdb.PrintingAttributes) {
+		attrs.FactionID, attrs.SideID, attrs.CardTypeID = "criminal", "runner", "runner_identity"
+		attrs.BaseLink = ptr(0)
+	}),
+}
+
+func TestMatch(t *testing.T) {
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: []string{"Tollbooth", "Ice Wall", "Cleaver", "Mutual Favor", "Diversion of Funds", "Zahya Sadeghi: Versatile Smuggler"}},
+		{query: "wall", want: []string{"Ice Wall"}},
+		{query: "TOLL", want: []string{"Tollbooth"}},
+		{query: "type:ice", want: []string{"Tollbooth", "Ice Wall"}},
+		{query: "t:identity", want: []string{"Zahya Sadeghi: Versatile Smuggler"}},
+		{query: "f:anarch|criminal", want: []string{"Cleaver", "Diversion of Funds", "Zahya Sadeghi: Versatile Smuggler"}},
+		{query: "faction!criminal side=runner", want: []string{"Cleaver", "Mutual Favor"}},
+		{query: "f:haas-bioroid", want: []string{"Ice Wall"}},
+		{query: "s:code_gate", want: []string{"Tollbooth"}},
+		{query: "subtype:fracter", want: []string{"Cleaver"}},
+		{query: `text:"end the run"`, want: []string{"Tollbooth", "Ice Wall"}},
+		{query: "cost<=1", want: []string{"Ice Wall", "Mutual Favor"}},
+		{query: "cost>1 cost<8", want: []string{"Cleaver"}},
+		{query: "cost:x", want: []string{"Diversion of Funds"}},
+		{query: "cost!x t:event", want: []string{"Mutual Favor"}},
+		{query: "p>=3", want: []string{"Tollbooth", "Cleaver"}},
+		{query: "n:2", want: []string{"Tollbooth", "Cleaver"}},
+		{query: "m:1", want: []string{"Cleaver"}},
+		{query: "link:0", want: []string{"Zahya Sadeghi: Versatile Smuggler"}},
+		{query: "e:system_gateway", want: []string{"Cleaver"}},
+		{query: "t:agenda", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, printing := range q.Filter(printings) {
+				got = append(got, printing.Attributes.Title)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
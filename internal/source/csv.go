@@ -0,0 +1,600 @@
+package source
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mangofeet/nrdb-go"
+	"gopkg.in/yaml.v3"
+)
+
+// CSVOptions controls how a print & play spreadsheet is read
+type CSVOptions struct {
+	// StartRow is the first row holding a card, 1 based. Rows before
+	// the first card, the header row unless the sheet is in the legacy
+	// layout without one, are always skipped.
+	StartRow int
+	// Columns maps header names to card definition fields, for
+	// spreadsheets whose headers don't match the field names. A
+	// column mapped to "ignore" is skipped.
+	Columns map[string]string
+}
+
+// CSVError is a problem with a single cell, or with a whole row when
+// Column is empty
+type CSVError struct {
+	File   string
+	Row    int
+	Column string
+	Err    string
+}
+
+func (err CSVError) Error() string {
+	if err.Column == "" {
+		return fmt.Sprintf("%s: row %d: %s", err.File, err.Row, err.Err)
+	}
+	return fmt.Sprintf(`%s: row %d, column "%s": %s`, err.File, err.Row, err.Column, err.Err)
+}
+
+// csvAliases are the header names recognized without a column
+// mapping, on top of the field names themselves. Headers are compared
+// in lower case with anything but letters and digits replaced by an
+// underscore.
+var csvAliases = map[string]string{
+	"name":          "title",
+	"card":          "title",
+	"card_name":     "title",
+	"card_type":     "type",
+	"subtype":       "subtypes",
+	"keywords":      "subtypes",
+	"memory":        "memory_cost",
+	"mu":            "memory_cost",
+	"trash":         "trash_cost",
+	"points":        "agenda_points",
+	"agenda":        "agenda_points",
+	"advancement":   "advancement_requirement",
+	"advancements":  "advancement_requirement",
+	"base_link":     "link",
+	"deck":          "minimum_deck_size",
+	"deck_size":     "minimum_deck_size",
+	"flavor_text":   "flavor",
+	"flavour":       "flavor",
+	"card_text":     "text",
+	"printing_id":   "id",
+	"cost_to_rez":   "cost",
+	"influence_max": "influence_limit",
+}
+
+// summaryColumn holds the title, type line, stats and text of a card
+// in one cell, as written by the original print & play spreadsheet
+const summaryColumn = "summary"
+
+// legacyHeader names the columns of the original print & play sheet,
+// which was read by position. Only the named columns are used.
+var legacyHeader = []string{"", "Faction", "", "Title", "Type", "Summary"}
+
+// csvLayout is how the cells of a sheet are read
+type csvLayout struct {
+	header  []string
+	columns []string
+	// firstRow is the first row that can hold a card, 1 based
+	firstRow int
+}
+
+// layoutCSV finds the columns of a sheet from its header row, or
+// falls back to the legacy positional layout for sheets with legacy
+// rows whose header doesn't name the summary column, or that start
+// with a card right away
+func layoutCSV(records [][]string, mapping map[string]string) (csvLayout, error) {
+
+	columns, err := csvColumns(records[0], mapping)
+	headerless := isLegacyRow(records[0])
+	legacy := headerless || (!slices.Contains(columns, summaryColumn) && slices.ContainsFunc(records, isLegacyRow))
+	if !legacy {
+		if err != nil {
+			return csvLayout{}, err
+		}
+		return csvLayout{header: records[0], columns: columns, firstRow: 2}, nil
+	}
+
+	layout := csvLayout{header: legacyHeader, firstRow: 2}
+	for _, name := range legacyHeader {
+		layout.columns = append(layout.columns, headerKey(name))
+	}
+	if headerless {
+		layout.firstRow = 1
+	}
+	return layout, nil
+}
+
+// isLegacyRow is true for a card row of the legacy layout, which has
+// its summary in the sixth cell
+func isLegacyRow(record []string) bool {
+	return len(record) >= len(legacyHeader) && strings.Contains(record[5], "====")
+}
+
+// LoadCSV reads cards from a print & play spreadsheet export. Columns
+// are found by their header, see Definition for the field names.
+// Sheets in the original layout, with or without a header row, are
+// read by position instead. Each card gets "custom-" and its row
+// number as printing ID unless there is an ID column.
+//
+// Rows with problems are skipped and returned as CSVErrors alongside
+// the cards that could be read, the returned error is only set when
+// the file itself can't be read.
+func LoadCSV(path string, opts CSVOptions) (*Memory, []error, error) {
+
+	records, err := readCSV(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layout, err := layoutCSV(records, opts.Columns)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var printings []*nrdb.Printing
+	var rowErrs []error
+	for i := max(opts.StartRow, layout.firstRow) - 1; i < len(records); i++ {
+		row := i + 1
+
+		if isBlank(records[i]) {
+			continue
+		}
+
+		def, errs := csvDefinition(path, row, layout.header, layout.columns, records[i])
+		if len(errs) > 0 {
+			rowErrs = append(rowErrs, errs...)
+			continue
+		}
+
+		if def.ID == "" {
+			def.ID = customID(row)
+		}
+		if def.Position == 0 {
+			def.Position = row
+		}
+		printings = append(printings, def.Printing())
+	}
+
+	return NewMemory(printings, nil), rowErrs, nil
+}
+
+// LoadCSVColumn reads a column that isn't a card field, like the art
+// algorithm for each card, from the spreadsheet LoadCSV reads with the
+// same options. The values are keyed by the printing ID LoadCSV gives
+// the card on each row, empty cells are left out. The column is found
+// by its header compared like the field headers, a sheet without it
+// gives an empty map.
+func LoadCSVColumn(path string, opts CSVOptions, name string) (map[string]string, error) {
+
+	records, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := layoutCSV(records, opts.Columns)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// a legacy sheet without a header has nothing to find the column by
+	valueColumn, idColumn := -1, -1
+	if layout.firstRow > 1 {
+		for i, header := range records[0] {
+			if headerKey(header) == headerKey(name) {
+				valueColumn = i
+			}
+			if i < len(layout.columns) && layout.columns[i] == "id" {
+				idColumn = i
+			}
+		}
+	}
+
+	values := map[string]string{}
+	if valueColumn == -1 {
+		return values, nil
+	}
+
+	cell := func(record []string, column int) string {
+		if column == -1 || column >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[column])
+	}
+
+	for i := max(opts.StartRow, layout.firstRow) - 1; i < len(records); i++ {
+		value := cell(records[i], valueColumn)
+		if value == "" {
+			continue
+		}
+
+		id := cell(records[i], idColumn)
+		if id == "" {
+			id = customID(i + 1)
+		}
+		values[id] = value
+	}
+
+	return values, nil
+}
+
+func readCSV(path string) ([][]string, error) {
+
+	csvFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer csvFile.Close()
+
+	csvReader := csv.NewReader(csvFile)
+	// short rows are reported per row instead of failing the file
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+
+	return records, nil
+}
+
+// LoadColumnMapping reads a YAML or JSON object mapping spreadsheet
+// headers to card definition fields, e.g. {"Card Name": "title"}
+func LoadColumnMapping(path string) (map[string]string, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping map[string]string
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, definitionSyntaxError(path, err)
+	}
+
+	for header, field := range mapping {
+		if field == "ignore" || field == summaryColumn {
+			continue
+		}
+		if _, ok := definitionFields[field]; !ok {
+			return nil, fmt.Errorf(`%s: column "%s" is mapped to unknown field "%s"`, path, header, field)
+		}
+	}
+
+	return mapping, nil
+}
+
+// csvColumns works out the definition field for each column, empty
+// for columns that aren't used
+func csvColumns(header []string, mapping map[string]string) ([]string, error) {
+
+	normalized := map[string]string{}
+	for name, field := range mapping {
+		normalized[headerKey(name)] = field
+	}
+
+	columns := make([]string, len(header))
+	seen := map[string]string{}
+	for i, name := range header {
+
+		field, ok := mapping[name]
+		if !ok {
+			field, ok = normalized[headerKey(name)]
+		}
+		if !ok {
+			field = headerKey(name)
+			if alias, ok := csvAliases[field]; ok {
+				field = alias
+			}
+		}
+
+		if _, known := definitionFields[field]; !known && field != summaryColumn {
+			continue
+		}
+
+		if other, ok := seen[field]; ok {
+			return nil, fmt.Errorf(`columns "%s" and "%s" are both read as %s, map one of them to "ignore"`, other, name, field)
+		}
+		seen[field] = name
+		columns[i] = field
+	}
+
+	if len(seen) == 0 {
+		return nil, errors.New("no known columns in the header row")
+	}
+
+	return columns, nil
+}
+
+func headerKey(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_")
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// csvDefinition turns a row into a card definition. The row is
+// converted into the same document a definition file holds, with the
+// column number standing in for the line number, so the values are
+// checked by the same rules.
+func csvDefinition(path string, row int, header, columns, record []string) (*Definition, []error) {
+
+	var errs []error
+	fail := func(column int, format string, args ...any) {
+		errs = append(errs, CSVError{File: path, Row: row, Column: header[column-1], Err: fmt.Sprintf(format, args...)})
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(column int, field, value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+
+		valueNode := csvValue(field, value)
+		valueNode.Line = column
+
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field, Line: column},
+			valueNode,
+		)
+	}
+
+	for i, field := range columns {
+		if field == "" {
+			continue
+		}
+
+		column := i + 1
+		if i >= len(record) {
+			// missing trailing cells are treated as empty
+			continue
+		}
+
+		if field == summaryColumn {
+			fields, err := parseSummary(record[i], csvIdentity(columns, record))
+			if err != nil {
+				fail(column, "%s", err)
+				continue
+			}
+			for _, field := range fields {
+				// a title column wins over the summary's title line
+				if field[0] == "title" && slices.Contains(columns, "title") {
+					continue
+				}
+				add(column, field[0], field[1])
+			}
+			continue
+		}
+
+		add(column, field, record[i])
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	def, err := decodeDefinition(path, node)
+	if err != nil {
+		var joined interface{ Unwrap() []error }
+		if !errors.As(err, &joined) {
+			return nil, []error{err}
+		}
+
+		for _, err := range joined.Unwrap() {
+			var defErr DefinitionError
+			if !errors.As(err, &defErr) {
+				errs = append(errs, err)
+				continue
+			}
+
+			// missing fields have no column, and a summary column
+			// holds several fields, so name the field for those
+			csvErr := CSVError{File: path, Row: row, Err: defErr.Field + ": " + defErr.Err}
+			if column := defErr.Line; column > 0 && column <= len(header) {
+				csvErr.Column = header[column-1]
+				if columns[column-1] != summaryColumn {
+					csvErr.Err = defErr.Err
+				}
+			}
+			errs = append(errs, csvErr)
+		}
+		return nil, errs
+	}
+
+	return def, nil
+}
+
+// csvValue converts a cell into a document node, spreadsheet values
+// are less strict than a definition file so names are normalized to
+// IDs and a few spellings are accepted
+func csvValue(field, value string) *yaml.Node {
+
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+	}
+
+	switch field {
+	case "type":
+		return scalar("!!str", csvCardType(value))
+	case "faction":
+		return scalar("!!str", csvFaction(value))
+	case "text":
+		return scalar("!!str", csvText(value))
+	case "subtypes":
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, subtype := range strings.Split(strings.ReplaceAll(value, " - ", ","), ",") {
+			if subtype = strings.TrimSpace(subtype); subtype != "" {
+				seq.Content = append(seq.Content, scalar("!!str", subtype))
+			}
+		}
+		return seq
+	case "unique":
+		switch strings.ToLower(value) {
+		case "true", "yes", "y", "x", "1", "◆":
+			return scalar("!!bool", "true")
+		case "false", "no", "n", "0":
+			return scalar("!!bool", "false")
+		}
+		return scalar("!!str", value)
+	}
+
+	if definitionFields[field] == kindInt || definitionFields[field] == kindCost {
+		if _, err := strconv.Atoi(value); err == nil {
+			return scalar("!!int", value)
+		}
+	}
+
+	return scalar("!!str", value)
+}
+
+// csvIdentity is true when the type column of the row is an identity
+func csvIdentity(columns, record []string) bool {
+	for i, field := range columns {
+		if field == "type" && i < len(record) {
+			return isIdentity(csvCardType(strings.TrimSpace(record[i])))
+		}
+	}
+	return false
+}
+
+func isIdentity(cardType string) bool {
+	return strings.HasSuffix(cardType, "identity") || cardType == "id"
+}
+
+func csvCardType(value string) string {
+	switch strings.ToLower(value) {
+	case "runner-id", "runner id", "runner identity":
+		return "runner_identity"
+	case "corp-id", "corp id", "corp identity":
+		return "corp_identity"
+	}
+	return strings.ToLower(value)
+}
+
+func csvFaction(value string) string {
+	switch strings.ToLower(value) {
+	case "weyland":
+		return "weyland_consortium"
+	case "hb":
+		return "haas_bioroid"
+	}
+	return headerKey(value)
+}
+
+var csvTextReplacer = strings.NewReplacer(
+	"{mu}", "[mu]",
+	"{c}", "[credit]",
+	"{recurring}", "[recurring-credit]",
+	"{click}", "[click]",
+	"{sub}", "[subroutine]",
+	"{trash}", "[trash]",
+	"{interrupt}", "[interrupt]",
+	"{link}", "[link]",
+)
+
+func csvText(value string) string {
+	return csvTextReplacer.Replace(strings.Trim(value, "\n"))
+}
+
+// summaryStats are the labels used on the stats line of a summary
+var summaryStats = map[string]string{
+	"Advancements": "advancement_requirement",
+	"Cost":         "cost",
+	"Deck":         "minimum_deck_size",
+	"Influence":    "influence",
+	"Link":         "link",
+	"Memory":       "memory_cost",
+	"Points":       "agenda_points",
+	"Strength":     "strength",
+	"Trash":        "trash_cost",
+}
+
+// parseSummary splits a summary cell into field and value pairs. The
+// cell holds the title line, "====", then the type line, the stats
+// line and the text separated by "----":
+//
+//	◆ Title
+//	====
+//	Program: Icebreaker - Fracter
+//	----
+//	Cost: 3, Memory: 1, Strength: 2
+//	----
+//	Card text
+//
+// The title line gives the title, a ◆ marks the card unique.
+// Influence is the influence limit of an identity, given by identity
+// or the type line, and the influence cost of other cards.
+func parseSummary(summary string, identity bool) ([][2]string, error) {
+
+	top, rest, ok := strings.Cut(summary, "====")
+	if !ok {
+		return nil, errors.New(`summary has no "====" line after the title`)
+	}
+
+	sections := strings.Split(rest, "----")
+	for i, section := range sections {
+		sections[i] = strings.Trim(section, "\n\r ")
+	}
+
+	var fields [][2]string
+	if title := strings.TrimSpace(strings.ReplaceAll(top, "◆", "")); title != "" {
+		fields = append(fields, [2]string{"title", title})
+	}
+	if strings.Contains(top, "◆") {
+		fields = append(fields, [2]string{"unique", "true"})
+	}
+
+	// type line, subtypes follow the type name, e.g. "Ice: Code Gate - AP"
+	if len(sections) > 0 {
+		typeName, subtypes, ok := strings.Cut(sections[0], ":")
+		if ok && strings.TrimSpace(subtypes) != "" {
+			fields = append(fields, [2]string{"subtypes", subtypes})
+		}
+		identity = identity || isIdentity(csvCardType(strings.TrimSpace(typeName)))
+	}
+
+	if len(sections) > 1 && sections[1] != "" {
+		for _, stat := range strings.Split(sections[1], ",") {
+			label, value, ok := strings.Cut(stat, ":")
+			label = strings.TrimSpace(label)
+			if !ok {
+				return nil, fmt.Errorf(`summary stat "%s" has no value, expected "Label: value"`, strings.TrimSpace(stat))
+			}
+
+			field, ok := summaryStats[label]
+			if !ok {
+				return nil, fmt.Errorf(`unknown summary stat "%s"`, label)
+			}
+			// identities list their influence limit as influence
+			if field == "influence" && identity {
+				field = "influence_limit"
+			}
+			fields = append(fields, [2]string{field, value})
+		}
+	}
+
+	if len(sections) > 2 {
+		fields = append(fields, [2]string{"text", strings.Join(sections[2:], "\n")})
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,324 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cards.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCSV(t *testing.T) {
+
+	// wantErrs are the row, column and message of each row error
+	type rowErr struct {
+		row    int
+		column string
+		err    string
+	}
+
+	tests := []struct {
+		name     string
+		csv      string
+		opts     CSVOptions
+		wantIDs  []string
+		wantErrs []rowErr
+	}{
+		{
+			name:    "valid rows",
+			csv:     "Name,Type,Faction,Cost\nCleaver,Program,Anarch,3\nHedge Fund,operation,neutral_corp,5\n",
+			wantIDs: []string{"custom-2", "custom-3"},
+		},
+		{
+			name:    "id column",
+			csv:     "ID,Title,Type,Faction\nc1,Cleaver,program,anarch\n",
+			wantIDs: []string{"c1"},
+		},
+		{
+			name:    "blank rows and start row",
+			csv:     "Title,Type,Faction\nnotes,,\n,,\nCleaver,program,anarch\n",
+			opts:    CSVOptions{StartRow: 3},
+			wantIDs: []string{"custom-4"},
+		},
+		{
+			name:     "bad number",
+			csv:      "Title,Type,Faction,Cost\nCleaver,program,anarch,three\n",
+			wantErrs: []rowErr{{row: 2, column: "Cost", err: `must be a whole number or X, got "three"`}},
+		},
+		{
+			name:     "unknown type",
+			csv:      "Title,Type,Faction\nCleaver,gadget,anarch\n",
+			wantErrs: []rowErr{{row: 2, column: "Type", err: `unknown card type "gadget"`}},
+		},
+		{
+			name:     "missing field",
+			csv:      "Title,Type,Faction\nCleaver,program,\n",
+			wantErrs: []rowErr{{row: 2, err: "faction: is required"}},
+		},
+		{
+			name:     "identity without deck size",
+			csv:      "Title,Type,Faction,Influence Limit\nZahya,runner identity,criminal,15\n",
+			wantErrs: []rowErr{{row: 2, err: "minimum_deck_size: is required for identities"}},
+		},
+		{
+			name:    "bad rows don't hide good ones",
+			csv:     "Title,Type,Faction,Strength\nCleaver,program,anarch,x\nIce Wall,ice,haas_bioroid,1\n",
+			wantIDs: []string{"custom-3"},
+			wantErrs: []rowErr{
+				{row: 2, column: "Strength", err: `must be a whole number, got "x"`},
+			},
+		},
+		{
+			name:     "summary without title line",
+			csv:      "Summary,Type,Faction\nCleaver,program,anarch\n",
+			wantErrs: []rowErr{{row: 2, column: "Summary", err: `no "===="`}},
+		},
+		{
+			name:     "unknown summary stat",
+			csv:      "Summary,Type,Faction\n\"Cleaver\n====\nProgram: Icebreaker\n----\nSpeed: 3\",program,anarch\n",
+			wantErrs: []rowErr{{row: 2, column: "Summary", err: `unknown summary stat "Speed"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCSV(t, tt.csv)
+
+			mem, rowErrs, err := LoadCSV(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			printings, _ := mem.All()
+			if got := printingIDs(printings); !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("got printings %q, want %q", got, tt.wantIDs)
+			}
+
+			if len(rowErrs) != len(tt.wantErrs) {
+				t.Fatalf("got %d row errors %v, want %d", len(rowErrs), rowErrs, len(tt.wantErrs))
+			}
+			for i, want := range tt.wantErrs {
+				got, ok := rowErrs[i].(CSVError)
+				if !ok {
+					t.Fatalf("error %d is %T, want a CSVError", i, rowErrs[i])
+				}
+				if got.File != path || got.Row != want.row || got.Column != want.column || !strings.Contains(got.Err, want.err) {
+					t.Errorf("got row %d column %q %q, want row %d column %q %q", got.Row, got.Column, got.Err, want.row, want.column, want.err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCSVFileErrors(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		csv     string
+		opts    CSVOptions
+		wantErr string
+	}{
+		{name: "empty file", csv: "", wantErr: "is empty"},
+		{name: "no known columns", csv: "Foo,Bar\n1,2\n", wantErr: "no known columns"},
+		{name: "duplicate columns", csv: "Name,Title,Type,Faction\n", wantErr: `columns "Name" and "Title" are both read as title`},
+		{
+			name:    "mapping ignores a column",
+			csv:     "Name,Title,Type,Faction\n",
+			opts:    CSVOptions{Columns: map[string]string{"Name": "ignore"}},
+			wantErr: "",
+		},
+		{name: "malformed", csv: "Title,Type,Faction\n\"Cleaver,program,anarch\n", wantErr: "quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := LoadCSV(writeCSV(t, tt.csv), tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadCSVSummaryInfluence(t *testing.T) {
+
+	path := writeCSV(t, `Title,Summary,Type,Faction
+Zahya Sadeghi,"Zahya Sadeghi
+====
+Runner Identity: Cyborg
+----
+Link: 0, Deck: 40, Influence: 15",runner identity,criminal
+Cleaver,"Cleaver
+====
+Program: Icebreaker - Fracter
+----
+Cost: 3, Memory: 1, Strength: 3, Influence: 2",program,anarch
+`)
+
+	mem, rowErrs, err := LoadCSV(path, CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rowErrs) > 0 {
+		t.Fatal(rowErrs)
+	}
+
+	tests := []struct {
+		id                  string
+		wantCost, wantLimit *int
+	}{
+		{id: "custom-2", wantLimit: ptr(15)},
+		{id: "custom-3", wantCost: ptr(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			printing, err := mem.ByPrintingID(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			attrs := printing.Attributes
+			if !equalInt(attrs.InfluenceCost, tt.wantCost) {
+				t.Errorf("got influence cost %v, want %v", deref(attrs.InfluenceCost), deref(tt.wantCost))
+			}
+			if !equalInt(attrs.InfluenceLimit, tt.wantLimit) {
+				t.Errorf("got influence limit %v, want %v", deref(attrs.InfluenceLimit), deref(tt.wantLimit))
+			}
+		})
+	}
+}
+
+func ptr(value int) *int {
+	return &value
+}
+
+func equalInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func deref(value *int) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func TestLoadCSVKeepsRowOrder(t *testing.T) {
+
+	var rows strings.Builder
+	rows.WriteString("Title,Type,Faction\n")
+	var want []string
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&rows, "Card %d,program,anarch\n", i)
+		want = append(want, fmt.Sprintf("custom-%d", i+1))
+	}
+
+	mem, rowErrs, err := LoadCSV(writeCSV(t, rows.String()), CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rowErrs) > 0 {
+		t.Fatal(rowErrs)
+	}
+
+	printings, _ := mem.All()
+	if got := printingIDs(printings); !slices.Equal(got, want) {
+		t.Errorf("got printings %q, want %q", got, want)
+	}
+}
+
+func TestLoadCSVLegacyLayout(t *testing.T) {
+
+	const rows = `1,Anarch,core,Cleaver,Program,"Cleaver
+====
+Program: Icebreaker - Fracter
+----
+Cost: 3, Memory: 1, Strength: 3, Influence: 2
+----
+Interface → {c}: Break up to 2 code gate subroutines."
+2,Weyland,core,Hedge Fund,Operation,"Hedge Fund
+====
+Operation: Transaction
+----
+Cost: 5, Influence: 0
+----
+Gain 9{c}."
+`
+
+	for name, sheet := range map[string]struct {
+		csv     string
+		opts    CSVOptions
+		wantIDs []string
+	}{
+		"without header":     {csv: rows, wantIDs: []string{"custom-1", "custom-2"}},
+		"start row":          {csv: rows, opts: CSVOptions{StartRow: 2}, wantIDs: []string{"custom-2"}},
+		"with unknown names": {csv: "#,Side,Pack,Card,Kind,Details\n" + rows, wantIDs: []string{"custom-2", "custom-3"}},
+	} {
+		mem, rowErrs, err := LoadCSV(writeCSV(t, sheet.csv), sheet.opts)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if len(rowErrs) > 0 {
+			t.Fatalf("%s: %v", name, rowErrs)
+		}
+
+		printings, _ := mem.All()
+		if got := printingIDs(printings); !slices.Equal(got, sheet.wantIDs) {
+			t.Fatalf("%s: got printings %q, want %q", name, got, sheet.wantIDs)
+		}
+
+		hedgeFund := printings[len(printings)-1].Attributes
+		if hedgeFund.Title != "Hedge Fund" || hedgeFund.FactionID != "weyland_consortium" || hedgeFund.CardTypeID != "operation" || hedgeFund.Text != "Gain 9[credit]." {
+			t.Errorf("%s: got %s, %s, %s, %q", name, hedgeFund.Title, hedgeFund.FactionID, hedgeFund.CardTypeID, hedgeFund.Text)
+		}
+	}
+}
+
+func TestLoadCSVTitleFromSummary(t *testing.T) {
+
+	path := writeCSV(t, `Summary,Type,Faction
+"◆ Zahya Sadeghi: Versatile Smuggler
+====
+Runner Identity: Cyborg
+----
+Link: 0, Deck: 40, Influence: 15",runner identity,criminal
+`)
+
+	mem, rowErrs, err := LoadCSV(path, CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rowErrs) > 0 {
+		t.Fatal(rowErrs)
+	}
+
+	zahya, err := mem.ByPrintingID("custom-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := zahya.Attributes.Title; got != "Zahya Sadeghi: Versatile Smuggler" {
+		t.Errorf("got title %q", got)
+	}
+	if !zahya.Attributes.IsUnique {
+		t.Error("the ◆ on the title line doesn't make the card unique")
+	}
+}
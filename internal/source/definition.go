@@ -0,0 +1,395 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mangofeet/nrdb-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition is a custom card written by hand, for fan-made and
+// playtest cards that aren't on NRDB. Definitions are read from YAML
+// or JSON files, the keys are the yaml tags below. Only title, type
+// and faction are required, any other field is left off the card
+// when missing.
+type Definition struct {
+	// ID is used as the printing ID, it defaults to "custom-" and the
+	// position of the card in the file
+	ID       string `yaml:"id"`
+	Title    string `yaml:"title"`
+	Type     string `yaml:"type"`
+	Faction  string `yaml:"faction"`
+	Set      string `yaml:"set"`
+	Position int    `yaml:"position"`
+
+	Unique   bool     `yaml:"unique"`
+	Subtypes []string `yaml:"subtypes"`
+
+	// Cost and AdvancementRequirement are numbers or X
+	Cost                   *string `yaml:"cost"`
+	AdvancementRequirement *string `yaml:"advancement_requirement"`
+
+	Strength        *int `yaml:"strength"`
+	MemoryCost      *int `yaml:"memory_cost"`
+	TrashCost       *int `yaml:"trash_cost"`
+	Influence       *int `yaml:"influence"`
+	AgendaPoints    *int `yaml:"agenda_points"`
+	Link            *int `yaml:"link"`
+	MemoryUnits     *int `yaml:"memory_units"`
+	MinimumDeckSize *int `yaml:"minimum_deck_size"`
+	InfluenceLimit  *int `yaml:"influence_limit"`
+	DeckLimit       *int `yaml:"deck_limit"`
+
+	Pronouns *string `yaml:"pronouns"`
+	Text     string  `yaml:"text"`
+	Flavor   string  `yaml:"flavor"`
+}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindInt
+	kindCost
+	kindBool
+	kindList
+)
+
+var definitionFields = map[string]fieldKind{
+	"id":                      kindString,
+	"title":                   kindString,
+	"type":                    kindString,
+	"faction":                 kindString,
+	"set":                     kindString,
+	"position":                kindInt,
+	"unique":                  kindBool,
+	"subtypes":                kindList,
+	"cost":                    kindCost,
+	"advancement_requirement": kindCost,
+	"strength":                kindInt,
+	"memory_cost":             kindInt,
+	"trash_cost":              kindInt,
+	"influence":               kindInt,
+	"agenda_points":           kindInt,
+	"link":                    kindInt,
+	"memory_units":            kindInt,
+	"minimum_deck_size":       kindInt,
+	"influence_limit":         kindInt,
+	"deck_limit":              kindInt,
+	"pronouns":                kindString,
+	"text":                    kindString,
+	"flavor":                  kindString,
+}
+
+// CardTypes are the card type IDs the frames know how to draw
+var CardTypes = []string{
+	"agenda", "asset", "corp_identity", "event", "hardware", "ice",
+	"operation", "program", "resource", "runner_identity", "upgrade",
+}
+
+// Factions are the faction IDs the frames have symbols for
+var Factions = []string{
+	"anarch", "criminal", "shaper", "neutral_runner",
+	"haas_bioroid", "jinteki", "nbn", "weyland_consortium", "neutral_corp",
+}
+
+var corpTypes = []string{"agenda", "asset", "corp_identity", "ice", "operation", "upgrade"}
+
+// DefinitionError is a problem with one field of a card definition
+type DefinitionError struct {
+	File  string
+	Line  int
+	Field string
+	Err   string
+}
+
+func (err DefinitionError) Error() string {
+	location := err.File
+	if err.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, err.Line)
+	}
+	if err.Field == "" {
+		return fmt.Sprintf("%s: %s", location, err.Err)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, err.Field, err.Err)
+}
+
+// LoadDefinitions reads custom cards from a YAML or JSON file holding
+// either a single card or a list of cards. Every problem in the file
+// is reported, each as a DefinitionError joined into the returned
+// error.
+func LoadDefinitions(path string) (*Memory, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defs, err := ParseDefinitions(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	printings := make([]*nrdb.Printing, len(defs))
+	for i, def := range defs {
+		printings[i] = def.Printing()
+	}
+
+	return NewMemory(printings, nil), nil
+}
+
+// ParseDefinitions validates and decodes card definitions, name is
+// only used in error messages
+func ParseDefinitions(name string, data []byte) ([]*Definition, error) {
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, definitionSyntaxError(name, err)
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, DefinitionError{File: name, Err: "no card definition"}
+	}
+
+	var nodes []*yaml.Node
+	switch root := doc.Content[0]; root.Kind {
+	case yaml.MappingNode:
+		nodes = []*yaml.Node{root}
+	case yaml.SequenceNode:
+		nodes = root.Content
+	default:
+		return nil, DefinitionError{File: name, Line: root.Line, Err: "expected a card or a list of cards"}
+	}
+
+	var errs []error
+	var defs []*Definition
+	for i, node := range nodes {
+		def, err := decodeDefinition(name, node)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if def.ID == "" {
+			def.ID = customID(i + 1)
+		}
+		if def.Position == 0 {
+			def.Position = i + 1
+		}
+		defs = append(defs, def)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return defs, nil
+}
+
+// customID is the printing ID of the nth card of a file without one,
+// plain numbers would be taken for NRDB printings in a chain
+func customID(n int) string {
+	return fmt.Sprintf("custom-%d", n)
+}
+
+func decodeDefinition(name string, node *yaml.Node) (*Definition, error) {
+
+	if node.Kind != yaml.MappingNode {
+		return nil, DefinitionError{File: name, Line: node.Line, Err: "expected a card"}
+	}
+
+	var errs []error
+	fail := func(line int, field, format string, args ...any) {
+		errs = append(errs, DefinitionError{File: name, Line: line, Field: field, Err: fmt.Sprintf(format, args...)})
+	}
+
+	values := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		kind, ok := definitionFields[key.Value]
+		if !ok {
+			fail(key.Line, key.Value, "unknown field")
+			continue
+		}
+		if _, ok := values[key.Value]; ok {
+			fail(key.Line, key.Value, "set more than once")
+			continue
+		}
+		values[key.Value] = value
+
+		if value.Tag == "!!null" {
+			continue
+		}
+
+		switch kind {
+		case kindString:
+			if value.Kind != yaml.ScalarNode {
+				fail(value.Line, key.Value, "must be text")
+			}
+		case kindInt:
+			if value.Tag != "!!int" {
+				fail(value.Line, key.Value, `must be a whole number, got "%s"`, value.Value)
+			}
+		case kindCost:
+			if value.Tag != "!!int" && !strings.EqualFold(value.Value, "X") {
+				fail(value.Line, key.Value, `must be a whole number or X, got "%s"`, value.Value)
+			}
+		case kindBool:
+			if value.Tag != "!!bool" {
+				fail(value.Line, key.Value, `must be true or false, got "%s"`, value.Value)
+			}
+		case kindList:
+			if value.Kind == yaml.ScalarNode {
+				// allow a single subtype without brackets
+				continue
+			}
+			if value.Kind != yaml.SequenceNode {
+				fail(value.Line, key.Value, "must be a list")
+				continue
+			}
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					fail(item.Line, key.Value, "must be a list of text")
+				}
+			}
+		}
+	}
+
+	for _, field := range []string{"title", "type", "faction"} {
+		if value, ok := values[field]; !ok || value.Value == "" {
+			fail(node.Line, field, "is required")
+		}
+	}
+
+	if value, ok := values["type"]; ok && value.Value != "" && !slices.Contains(CardTypes, value.Value) {
+		fail(value.Line, "type", `unknown card type "%s", expected one of %s`, value.Value, strings.Join(CardTypes, ", "))
+	}
+	if value, ok := values["faction"]; ok && value.Value != "" && !slices.Contains(Factions, value.Value) {
+		fail(value.Line, "faction", `unknown faction "%s", expected one of %s`, value.Value, strings.Join(Factions, ", "))
+	}
+
+	// identities always show their deck size and influence limit
+	if value, ok := values["type"]; ok && strings.HasSuffix(value.Value, "_identity") {
+		for _, field := range []string{"minimum_deck_size", "influence_limit"} {
+			if _, ok := values[field]; !ok {
+				fail(node.Line, field, "is required for identities")
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool {
+			return errs[i].(DefinitionError).Line < errs[j].(DefinitionError).Line
+		})
+		return nil, errors.Join(errs...)
+	}
+
+	// single subtypes are decoded as a list
+	if value, ok := values["subtypes"]; ok && value.Kind == yaml.ScalarNode && value.Tag != "!!null" {
+		*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Value}}}
+	}
+
+	var def Definition
+	if err := node.Decode(&def); err != nil {
+		return nil, definitionSyntaxError(name, err)
+	}
+
+	return &def, nil
+}
+
+func definitionSyntaxError(name string, err error) error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		err = errors.New(strings.Join(typeErr.Errors, ", "))
+	}
+
+	// yaml errors are formatted as "yaml: line 3: ..."
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	if rest, ok := strings.CutPrefix(msg, "line "); ok {
+		if lineText, text, ok := strings.Cut(rest, ": "); ok {
+			if line, err := strconv.Atoi(lineText); err == nil {
+				return DefinitionError{File: name, Line: line, Err: text}
+			}
+		}
+	}
+
+	return DefinitionError{File: name, Err: msg}
+}
+
+// Printing converts the definition into the printing the frames draw
+func (def *Definition) Printing() *nrdb.Printing {
+
+	printing := &nrdb.Printing{}
+	printing.ID = def.ID
+	printing.Attributes = &nrdb.PrintingAttributes{}
+	attrs := printing.Attributes
+
+	attrs.CardID = def.ID
+	attrs.Title = def.Title
+	attrs.StrippedTitle = def.Title
+	attrs.CardTypeID = def.Type
+	attrs.FactionID = def.Faction
+	attrs.CardSetID = def.Set
+	attrs.Position = def.Position
+	attrs.PositionInSet = def.Position
+	attrs.IsLatestPrinting = true
+
+	attrs.SideID = "runner"
+	if slices.Contains(corpTypes, def.Type) {
+		attrs.SideID = "corp"
+	}
+
+	attrs.IsUnique = def.Unique
+	if len(def.Subtypes) > 0 {
+		subtypes := strings.Join(def.Subtypes, " - ")
+		attrs.DisplaySubtypes = &subtypes
+		for _, subtype := range def.Subtypes {
+			attrs.CardSubtypeIDs = append(attrs.CardSubtypeIDs, strings.ToLower(strings.ReplaceAll(subtype, " ", "_")))
+		}
+		attrs.CardSubtypeNames = def.Subtypes
+	}
+
+	attrs.Cost = upperX(def.Cost)
+	attrs.AdvancementRequirement = upperX(def.AdvancementRequirement)
+	attrs.Strength = def.Strength
+	attrs.MemoryCost = def.MemoryCost
+	attrs.TrashCost = def.TrashCost
+	attrs.InfluenceCost = def.Influence
+	attrs.AgendaPoints = def.AgendaPoints
+	attrs.BaseLink = def.Link
+	attrs.MinimumDeckSize = def.MinimumDeckSize
+	attrs.InfluenceLimit = def.InfluenceLimit
+
+	attrs.DeckLimit = 3
+	if def.DeckLimit != nil {
+		attrs.DeckLimit = *def.DeckLimit
+	} else if strings.HasSuffix(def.Type, "_identity") {
+		attrs.DeckLimit = 1
+	}
+
+	attrs.CardAbilities = &nrdb.CardAbilities{
+		MUProvided:   def.MemoryUnits,
+		LinkProvided: def.Link,
+	}
+
+	attrs.Pronouns = def.Pronouns
+	attrs.Text = strings.TrimSpace(def.Text)
+	attrs.StrippedText = attrs.Text
+	attrs.Flavor = strings.TrimSpace(def.Flavor)
+
+	return printing
+}
+
+func upperX(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	upper := strings.ToUpper(*value)
+	return &upper
+}
@@ -0,0 +1,77 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// LoadDump reads a local copy of the NRDB v3 API, so lookups work
+// without network access.
+//
+// The directory holds JSON:API responses as returned by the public
+// API. Files matching printings*.json contain printing documents and
+// files matching cards*.json contain card documents, so a paged
+// download can be stored as printings-1.json, printings-2.json and so
+// on. Each file may either be a full response ({"data": [...]}) or a
+// bare array of documents. The card files are optional, cards are
+// derived from the printings when they are missing.
+func LoadDump(dir string) (*Memory, error) {
+
+	printingFiles, err := filepath.Glob(filepath.Join(dir, "printings*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(printingFiles) == 0 {
+		return nil, fmt.Errorf("no printings*.json files in %s", dir)
+	}
+
+	cardFiles, err := filepath.Glob(filepath.Join(dir, "cards*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var printings []*nrdb.Printing
+	for _, name := range printingFiles {
+		var filePrintings []*nrdb.Printing
+		if err := readDocuments(name, &filePrintings); err != nil {
+			return nil, err
+		}
+		printings = append(printings, filePrintings...)
+	}
+
+	var cards []*nrdb.Card
+	for _, name := range cardFiles {
+		var fileCards []*nrdb.Card
+		if err := readDocuments(name, &fileCards); err != nil {
+			return nil, err
+		}
+		cards = append(cards, fileCards...)
+	}
+
+	return NewMemory(printings, cards), nil
+}
+
+// readDocuments decodes either a JSON:API response or a bare array of
+// documents into out
+func readDocuments[T any](name string, out *[]T) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	var res nrdb.Response[[]T]
+	if err := json.Unmarshal(data, &res); err == nil {
+		*out = res.Data
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeDump writes a dump directory in both file layouts, a full
+// response and a bare array, and returns its path
+func writeDump(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const (
+	dumpPage1 = `{"data": [
+		{"id": "01050", "type": "printings", "attributes": {"title": "Hedge Fund", "stripped_title": "Hedge Fund", "card_id": "hedge_fund"}},
+		{"id": "20132", "type": "printings", "attributes": {"title": "Hedge Fund", "stripped_title": "Hedge Fund", "card_id": "hedge_fund"}}
+	]}`
+	dumpPage2 = `[
+		{"id": "30076", "type": "printings", "attributes": {"title": "Sure Gamble", "stripped_title": "Sure Gamble", "card_id": "sure_gamble"}},
+		{"id": "26010", "type": "printings", "attributes": {"title": "Café Society", "stripped_title": "Cafe Society", "card_id": "cafe_society"}}
+	]`
+)
+
+func TestLoadDump(t *testing.T) {
+
+	dump, err := LoadDump(writeDump(t, map[string]string{
+		"printings-1.json": dumpPage1,
+		"printings-2.json": dumpPage2,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	printing, err := dump.ByPrintingID("30076")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if printing.Attributes.Title != "Sure Gamble" {
+		t.Errorf("got %s for 30076, want Sure Gamble", printing.Attributes.Title)
+	}
+	if _, err := dump.ByPrintingID("99999"); err == nil {
+		t.Error("expected an error for a printing that isn't in the dump")
+	}
+
+	tests := []struct {
+		search        string
+		want          []string
+		wantPrintings []string
+	}{
+		{search: "hedge", want: []string{"Hedge Fund"}, wantPrintings: []string{"01050", "20132"}},
+		{search: "  SURE   gamble", want: []string{"Sure Gamble"}, wantPrintings: []string{"30076"}},
+		{search: "cafe", want: []string{"Café Society"}, wantPrintings: []string{"26010"}},
+		{search: "siphon", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			cards, err := dump.Search(tt.search)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			var printings []string
+			for _, card := range cards {
+				got = append(got, card.Attributes.Title)
+				printings = append(printings, card.Attributes.PrintingIDs...)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(printings, tt.wantPrintings) {
+				t.Errorf("got printings %q, want %q", printings, tt.wantPrintings)
+			}
+		})
+	}
+}
+
+func TestLoadDumpErrors(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "no printings", files: map[string]string{"cards.json": "[]"}},
+		{name: "invalid json", files: map[string]string{"printings.json": `{"data": [`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadDump(writeDump(t, tt.files)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package source
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// MatchKind describes how well a card title matched a search, lower
+// is better
+type MatchKind int
+
+const (
+	MatchExact MatchKind = iota
+	MatchPrefix
+	MatchContains
+	MatchFuzzy
+	MatchNone
+)
+
+func (kind MatchKind) String() string {
+	switch kind {
+	case MatchExact:
+		return "exact"
+	case MatchPrefix:
+		return "prefix"
+	case MatchContains:
+		return "contains"
+	case MatchFuzzy:
+		return "similar"
+	}
+	return "none"
+}
+
+type Match struct {
+	Card     *nrdb.Card
+	Kind     MatchKind
+	Distance int
+}
+
+// Rank orders cards by how well their title matches the query: exact
+// matches first, then titles starting with the query, titles
+// containing it and finally titles within a small edit distance.
+// Cards that don't match at all are dropped.
+func Rank(query string, cards []*nrdb.Card) []Match {
+
+	query = normalizeTitle(query)
+
+	var matches []Match
+	for _, card := range cards {
+		if card == nil || card.Attributes == nil {
+			continue
+		}
+
+		match := matchCard(query, card)
+		if match.Kind == MatchNone {
+			continue
+		}
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		return a.Card.Attributes.StrippedTitle < b.Card.Attributes.StrippedTitle
+	})
+
+	return matches
+}
+
+func matchCard(query string, card *nrdb.Card) Match {
+
+	best := Match{
+		Card: card,
+		Kind: MatchNone,
+	}
+
+	for _, title := range []string{card.Attributes.Title, card.Attributes.StrippedTitle} {
+		title = normalizeTitle(title)
+		if title == "" {
+			continue
+		}
+
+		match := Match{Card: card, Kind: MatchNone}
+		switch {
+		case title == query:
+			match.Kind = MatchExact
+		case strings.HasPrefix(title, query):
+			match.Kind = MatchPrefix
+			match.Distance = len(title) - len(query)
+		case strings.Contains(title, query):
+			match.Kind = MatchContains
+			match.Distance = strings.Index(title, query)
+		default:
+			distance := editDistance(query, title)
+			if distance <= maxEditDistance(query) {
+				match.Kind = MatchFuzzy
+				match.Distance = distance
+			}
+		}
+
+		if match.Kind < best.Kind || (match.Kind == best.Kind && match.Distance < best.Distance) {
+			best = match
+		}
+	}
+
+	return best
+}
+
+// maxEditDistance is how many typos are allowed for a query, roughly
+// one per four characters
+func maxEditDistance(query string) int {
+	return len([]rune(query))/4 + 1
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(br)]
+}
+
+// Cards builds card documents out of the card attributes embedded in
+// each printing, for sources that only list printings
+func Cards(printings []*nrdb.Printing) []*nrdb.Card {
+	return cardsFromPrintings(printings)
+}
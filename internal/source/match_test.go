@@ -0,0 +1,61 @@
+package source
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+func TestRank(t *testing.T) {
+
+	cafe := printing("c1", "Café Society")
+	cafe.Attributes.StrippedTitle = "Cafe Society"
+
+	cards := Cards([]*nrdb.Printing{
+		printing("01050", "Hedge Fund"),
+		printing("01051", "Hedge Funds"),
+		printing("01052", "Hedge Fund Manager"),
+		printing("01053", "Big Hedge Fund"),
+		printing("01054", "Hedge Fumd"),
+		printing("01055", "Sure Gamble"),
+		cafe,
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "hedge fund", want: []string{
+			"Hedge Fund exact",
+			"Hedge Funds prefix",
+			"Hedge Fund Manager prefix",
+			"Big Hedge Fund contains",
+			"Hedge Fumd similar",
+		}},
+		{query: "  HEDGE   fund ", want: []string{
+			"Hedge Fund exact",
+			"Hedge Funds prefix",
+			"Hedge Fund Manager prefix",
+			"Big Hedge Fund contains",
+			"Hedge Fumd similar",
+		}},
+		{query: "sure gambel", want: []string{"Sure Gamble similar"}},
+		{query: "cafe", want: []string{"Café Society prefix"}},
+		{query: "society", want: []string{"Café Society contains"}},
+		{query: "account siphon", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			for _, match := range Rank(tt.query, cards) {
+				got = append(got, fmt.Sprintf("%s %s", match.Card.Attributes.Title, match.Kind))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
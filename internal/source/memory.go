@@ -0,0 +1,171 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// Memory is a source holding a fixed set of printings, used for
+// local files and as a fixture set that never touches the network
+type Memory struct {
+	printings []*nrdb.Printing
+	byID      map[string]*nrdb.Printing
+	cards     []*nrdb.Card
+}
+
+// NewMemory creates a source from printings and their cards. When no
+// cards are given they are derived from the printings.
+func NewMemory(printings []*nrdb.Printing, cards []*nrdb.Card) *Memory {
+
+	mem := Memory{
+		byID: map[string]*nrdb.Printing{},
+	}
+
+	for _, printing := range printings {
+		if printing == nil || printing.Attributes == nil {
+			continue
+		}
+		mem.printings = append(mem.printings, printing)
+		mem.byID[printing.ID] = printing
+	}
+
+	for _, card := range cards {
+		if card == nil || card.Attributes == nil {
+			continue
+		}
+		mem.cards = append(mem.cards, card)
+	}
+
+	if len(mem.cards) == 0 {
+		mem.cards = cardsFromPrintings(mem.printings)
+	}
+
+	sort.SliceStable(mem.printings, func(i, j int) bool {
+		return printingLess(mem.printings[i], mem.printings[j])
+	})
+
+	sort.Slice(mem.cards, func(i, j int) bool {
+		return mem.cards[i].Attributes.StrippedTitle < mem.cards[j].Attributes.StrippedTitle
+	})
+
+	return &mem
+}
+
+// Fixture creates a source from a list of printings
+func Fixture(printings ...*nrdb.Printing) *Memory {
+	return NewMemory(printings, nil)
+}
+
+func (mem *Memory) ByPrintingID(printingID string) (*nrdb.Printing, error) {
+	printing, ok := mem.byID[printingID]
+	if !ok {
+		return nil, fmt.Errorf("printing %s not found", printingID)
+	}
+	return printing, nil
+}
+
+// Search matches case-insensitively on any part of the title, the
+// same way the NRDB API does for plain searches
+func (mem *Memory) Search(query string) ([]*nrdb.Card, error) {
+
+	search := normalizeTitle(query)
+	if search == "" {
+		return nil, errors.New("empty search")
+	}
+
+	var cards []*nrdb.Card
+	for _, card := range mem.cards {
+		if strings.Contains(normalizeTitle(card.Attributes.Title), search) ||
+			strings.Contains(normalizeTitle(card.Attributes.StrippedTitle), search) {
+			cards = append(cards, card)
+		}
+	}
+
+	return cards, nil
+}
+
+func (mem *Memory) All() ([]*nrdb.Printing, error) {
+	return mem.printings, nil
+}
+
+func normalizeTitle(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), " ")
+}
+
+// cardsFromPrintings builds card documents out of the card attributes
+// embedded in each printing
+func cardsFromPrintings(printings []*nrdb.Printing) []*nrdb.Card {
+
+	var cardIDs []string
+	byCard := map[string][]*nrdb.Printing{}
+	for _, printing := range printings {
+		cardID := printing.Attributes.CardID
+		if cardID == "" {
+			cardID = printing.ID
+		}
+		if _, ok := byCard[cardID]; !ok {
+			cardIDs = append(cardIDs, cardID)
+		}
+		byCard[cardID] = append(byCard[cardID], printing)
+	}
+
+	var cards []*nrdb.Card
+	for _, cardID := range cardIDs {
+		cardPrintings := byCard[cardID]
+
+		sort.SliceStable(cardPrintings, func(i, j int) bool {
+			return printingLess(cardPrintings[i], cardPrintings[j])
+		})
+
+		latest := cardPrintings[len(cardPrintings)-1]
+		for _, printing := range cardPrintings {
+			if printing.Attributes.IsLatestPrinting {
+				latest = printing
+			}
+		}
+
+		attributes := latest.Attributes.CardAttributes
+		attributes.LatestPrintingID = latest.ID
+		attributes.PrintingIDs = nil
+		for _, printing := range cardPrintings {
+			attributes.PrintingIDs = append(attributes.PrintingIDs, printing.ID)
+		}
+		attributes.NumPrintings = len(cardPrintings)
+
+		card := &nrdb.Card{}
+		card.ID = cardID
+		card.Type = "cards"
+		card.Attributes = &attributes
+		cards = append(cards, card)
+	}
+
+	return cards
+}
+
+// printingLess orders printings by numeric ID where possible, which
+// follows release order on NRDB. IDs sharing a prefix compare their
+// trailing number, so custom-2 comes before custom-10.
+func printingLess(a, b *nrdb.Printing) bool {
+	aPrefix, aNum := splitID(a.ID)
+	bPrefix, bNum := splitID(b.ID)
+	if aPrefix == bPrefix && aNum >= 0 && bNum >= 0 && aNum != bNum {
+		return aNum < bNum
+	}
+	return a.ID < b.ID
+}
+
+// splitID splits an ID into its prefix and trailing number, the
+// number is -1 when there isn't one
+func splitID(id string) (string, int) {
+	prefix := strings.TrimRight(id, "0123456789")
+	num, err := strconv.Atoi(id[len(prefix):])
+	if err != nil {
+		return id, -1
+	}
+	return prefix, num
+}
@@ -0,0 +1,38 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// NRDB is a source backed by the NRDB API
+type NRDB struct {
+	client nrdb.Client
+}
+
+func NewNRDB(client nrdb.Client) NRDB {
+	return NRDB{
+		client: client,
+	}
+}
+
+// ByPrintingID only asks NRDB for numeric IDs, anything else can't be
+// one of its printings and would be a wasted request
+func (src NRDB) ByPrintingID(printingID string) (*nrdb.Printing, error) {
+	if _, err := strconv.Atoi(printingID); err != nil {
+		return nil, fmt.Errorf("printing %s not found", printingID)
+	}
+	return src.client.Printing(printingID)
+}
+
+func (src NRDB) Search(query string) ([]*nrdb.Card, error) {
+	return src.client.Cards(&nrdb.CardFilter{
+		Search: &query,
+	})
+}
+
+func (src NRDB) All() ([]*nrdb.Printing, error) {
+	return src.client.AllPrintings(nil)
+}
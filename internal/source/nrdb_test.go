@@ -0,0 +1,43 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// printingClient answers printing lookups and records what was asked,
+// every other call panics through the nil interface
+type printingClient struct {
+	nrdb.Client
+	requested []string
+}
+
+func (client *printingClient) Printing(printingID string) (*nrdb.Printing, error) {
+	client.requested = append(client.requested, printingID)
+	return printing(printingID, "Hedge Fund"), nil
+}
+
+func TestNRDBByPrintingIDSkipsNonNumericIDs(t *testing.T) {
+
+	client := &printingClient{}
+	src := NewNRDB(client)
+
+	if _, err := src.ByPrintingID("hedge fund"); err == nil {
+		t.Error("expected a title to not be found as a printing ID")
+	}
+	if _, err := src.ByPrintingID("custom-3"); err == nil {
+		t.Error("expected a custom ID to not be found on NRDB")
+	}
+	if len(client.requested) > 0 {
+		t.Fatalf("requested %q from NRDB", client.requested)
+	}
+
+	got, err := src.ByPrintingID("01050")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "01050" || len(client.requested) != 1 {
+		t.Errorf("got %s after requesting %q", got.ID, client.requested)
+	}
+}
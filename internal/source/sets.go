@@ -0,0 +1,67 @@
+package source
+
+import (
+	"sort"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// setSource is implemented by sources that can look up a set without
+// listing every printing
+type setSource interface {
+	InSet(id string) ([]*nrdb.Printing, error)
+}
+
+// InSet returns every printing in a card set, or in every set of a
+// card cycle, ordered by position
+func InSet(src CardSource, id string) ([]*nrdb.Printing, error) {
+
+	var printings []*nrdb.Printing
+	if setSrc, ok := src.(setSource); ok {
+		found, err := setSrc.InSet(id)
+		if err != nil {
+			return nil, err
+		}
+		printings = found
+	} else {
+		all, err := src.All()
+		if err != nil {
+			return nil, err
+		}
+		for _, printing := range all {
+			if printing.Attributes.CardSetID == id || printing.Attributes.CardCycleID == id {
+				printings = append(printings, printing)
+			}
+		}
+	}
+
+	sort.SliceStable(printings, func(i, j int) bool {
+		return printingLess(printings[i], printings[j])
+	})
+
+	return printings, nil
+}
+
+func (src NRDB) InSet(id string) ([]*nrdb.Printing, error) {
+	printings, err := src.search("card_set:" + id)
+	if err != nil || len(printings) > 0 {
+		return printings, err
+	}
+	return src.search("card_cycle:" + id)
+}
+
+func (src NRDB) search(query string) ([]*nrdb.Printing, error) {
+	return src.client.AllPrintings(&nrdb.PrintingFilter{
+		CardFilter: nrdb.CardFilter{
+			Search: &query,
+		},
+	})
+}
+
+// InSet looks in every source of the chain, a source that fails is
+// skipped like in Search
+func (ch chain) InSet(id string) ([]*nrdb.Printing, error) {
+	return collect(ch, func(src CardSource) ([]*nrdb.Printing, error) {
+		return InSet(src, id)
+	})
+}
@@ -0,0 +1,244 @@
+// Package source provides the card data used for rendering, either
+// from NRDB or from local files.
+package source
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+// CardSource looks up cards and printings
+type CardSource interface {
+	// ByPrintingID returns a single printing
+	ByPrintingID(printingID string) (*nrdb.Printing, error)
+	// Search returns the cards with a title matching the query
+	Search(query string) ([]*nrdb.Card, error)
+	// All returns every printing known to the source
+	All() ([]*nrdb.Printing, error)
+}
+
+// Options configures the sources built by Parse
+type Options struct {
+	// Client is used for the nrdb source
+	Client nrdb.Client
+	// CSV is used for csv sources
+	CSV CSVOptions
+	// Warn is called with problems that don't stop a source from
+	// loading, like a CSV row that couldn't be read
+	Warn func(error)
+	// NRDBFallback answers the lookups the nrdb source fails, see
+	// Fallback
+	NRDBFallback CardSource
+}
+
+func (opts Options) warn(err error) {
+	if opts.Warn != nil {
+		opts.Warn(err)
+	}
+}
+
+// Parse builds a source from a comma separated list of specs. Each
+// spec is one of:
+//
+//	nrdb          the live NRDB API
+//	dump:<dir>    a local NRDB v3 JSON dump, see LoadDump
+//	csv:<file>    a print & play CSV, see LoadCSV
+//	def:<file>    custom card definitions, see LoadDefinitions
+//
+// When more than one spec is given the sources are chained in order.
+func Parse(specs string, opts Options) (CardSource, error) {
+	var sources []CardSource
+
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		kind, arg, _ := strings.Cut(spec, ":")
+
+		switch kind {
+		case "nrdb":
+			var nrdbSource CardSource = NewNRDB(opts.Client)
+			if opts.NRDBFallback != nil {
+				nrdbSource = Fallback(nrdbSource, opts.NRDBFallback)
+			}
+			sources = append(sources, nrdbSource)
+		case "dump":
+			dump, err := LoadDump(arg)
+			if err != nil {
+				return nil, fmt.Errorf("loading dump %s: %w", arg, err)
+			}
+			sources = append(sources, dump)
+		case "csv":
+			csvSource, rowErrs, err := LoadCSV(arg, opts.CSV)
+			if err != nil {
+				return nil, fmt.Errorf("loading csv %s: %w", arg, err)
+			}
+			for _, err := range rowErrs {
+				opts.warn(err)
+			}
+			sources = append(sources, csvSource)
+		case "def":
+			defs, err := LoadDefinitions(arg)
+			if err != nil {
+				return nil, fmt.Errorf("loading card definitions %s: %w", arg, err)
+			}
+			sources = append(sources, defs)
+		default:
+			return nil, fmt.Errorf(`unknown card source "%s"`, spec)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, errors.New("no card source given")
+	}
+
+	if len(sources) == 1 {
+		return sources[0], nil
+	}
+
+	return chain{sources: sources, warn: opts.warn}, nil
+}
+
+// Chain combines several sources. Printings are looked up in each
+// source in order, searches and listings return the results of all of
+// them.
+func Chain(sources ...CardSource) CardSource {
+	return chain{sources: sources}
+}
+
+type chain struct {
+	sources []CardSource
+	// warn is told about sources that failed while others answered
+	warn func(error)
+}
+
+func (ch chain) ByPrintingID(printingID string) (*nrdb.Printing, error) {
+	var errs []error
+	for _, src := range ch.sources {
+		printing, err := src.ByPrintingID(printingID)
+		if err == nil {
+			return printing, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Search returns what the sources that answered found, a source that
+// fails doesn't hide the cards of the others. It only fails when every
+// source does, otherwise the failures are passed to warn.
+func (ch chain) Search(query string) ([]*nrdb.Card, error) {
+	return collect(ch, func(src CardSource) ([]*nrdb.Card, error) {
+		return src.Search(query)
+	})
+}
+
+// All lists the printings of the sources that answered, like Search
+func (ch chain) All() ([]*nrdb.Printing, error) {
+	return collect(ch, CardSource.All)
+}
+
+// collect joins the results of lookup in every source of the chain,
+// skipping the sources that fail as long as one of them answers
+func collect[T any](ch chain, lookup func(CardSource) ([]T, error)) ([]T, error) {
+	var results []T
+	var errs []error
+	for _, src := range ch.sources {
+		found, err := lookup(src)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, found...)
+	}
+	if len(errs) == len(ch.sources) {
+		return nil, errors.Join(errs...)
+	}
+	if ch.warn != nil {
+		for _, err := range errs {
+			ch.warn(fmt.Errorf("skipped a card source: %w", err))
+		}
+	}
+	return results, nil
+}
+
+// RememberAll keeps the printings the first successful All of src
+// returns, so a source that downloads every printing does it once
+// however many lookups fall back to the full list
+func RememberAll(src CardSource) CardSource {
+	return &rememberAll{CardSource: src}
+}
+
+type rememberAll struct {
+	CardSource
+
+	mu        sync.Mutex
+	printings []*nrdb.Printing
+}
+
+func (src *rememberAll) All() ([]*nrdb.Printing, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.printings != nil {
+		return src.printings, nil
+	}
+
+	printings, err := src.CardSource.All()
+	if err != nil {
+		return nil, err
+	}
+	src.printings = printings
+	return printings, nil
+}
+
+// Fallback answers the lookups src fails with fallback, like title
+// searches that aren't in the cache when offline. A fallback that
+// has nothing either returns the error of src, so it isn't hidden
+// behind an empty result.
+func Fallback(src, fallback CardSource) CardSource {
+	return fallbackSource{src: src, fallback: fallback}
+}
+
+type fallbackSource struct {
+	src, fallback CardSource
+}
+
+func (src fallbackSource) ByPrintingID(printingID string) (*nrdb.Printing, error) {
+	printing, err := src.src.ByPrintingID(printingID)
+	if err == nil {
+		return printing, nil
+	}
+	if printing, fallbackErr := src.fallback.ByPrintingID(printingID); fallbackErr == nil {
+		return printing, nil
+	}
+	return nil, err
+}
+
+func (src fallbackSource) Search(query string) ([]*nrdb.Card, error) {
+	cards, err := src.src.Search(query)
+	if err == nil {
+		return cards, nil
+	}
+	if cards, fallbackErr := src.fallback.Search(query); fallbackErr == nil && len(cards) > 0 {
+		return cards, nil
+	}
+	return nil, err
+}
+
+func (src fallbackSource) All() ([]*nrdb.Printing, error) {
+	printings, err := src.src.All()
+	if err == nil {
+		return printings, nil
+	}
+	if printings, fallbackErr := src.fallback.All(); fallbackErr == nil && len(printings) > 0 {
+		return printings, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,289 @@
+package source
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mangofeet/nrdb-go"
+)
+
+func printing(id, title string) *nrdb.Printing {
+	return &nrdb.Printing{
+		Document: nrdb.Document[nrdb.PrintingAttributes, nrdb.PrintingRelationships]{
+			ID: id,
+			Attributes: &nrdb.PrintingAttributes{
+				CardAttributes: nrdb.CardAttributes{
+					Title:         title,
+					StrippedTitle: title,
+				},
+				CardID: id,
+			},
+		},
+	}
+}
+
+// broken is a source that fails every lookup, like NRDB when offline
+type broken struct{}
+
+var errBroken = errors.New("source unavailable")
+
+func (broken) ByPrintingID(string) (*nrdb.Printing, error) { return nil, errBroken }
+func (broken) Search(string) ([]*nrdb.Card, error)         { return nil, errBroken }
+func (broken) All() ([]*nrdb.Printing, error)              { return nil, errBroken }
+
+func titles(cards []*nrdb.Card) []string {
+	var result []string
+	for _, card := range cards {
+		result = append(result, card.Attributes.Title)
+	}
+	return result
+}
+
+func printingIDs(printings []*nrdb.Printing) []string {
+	var result []string
+	for _, printing := range printings {
+		result = append(result, printing.ID)
+	}
+	return result
+}
+
+func TestChainByPrintingID(t *testing.T) {
+
+	custom := Fixture(printing("01050", "Hedge Fund (alt)"), printing("c1", "Custom Card"))
+	base := Fixture(printing("01050", "Hedge Fund"), printing("01051", "Sure Gamble"))
+
+	tests := []struct {
+		name    string
+		sources []CardSource
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{name: "first source wins", sources: []CardSource{custom, base}, id: "01050", want: "Hedge Fund (alt)"},
+		{name: "order matters", sources: []CardSource{base, custom}, id: "01050", want: "Hedge Fund"},
+		{name: "falls back to later source", sources: []CardSource{custom, base}, id: "01051", want: "Sure Gamble"},
+		{name: "skips failing source", sources: []CardSource{broken{}, base}, id: "01051", want: "Sure Gamble"},
+		{name: "not found anywhere", sources: []CardSource{custom, base}, id: "99999", wantErr: true},
+		{name: "every source fails", sources: []CardSource{broken{}, broken{}}, id: "01050", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Chain(tt.sources...).ByPrintingID(tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got.Attributes.Title)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Attributes.Title != tt.want {
+				t.Errorf("got %s, want %s", got.Attributes.Title, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainSearch(t *testing.T) {
+
+	custom := Fixture(printing("c1", "Hedge Fund Manager"))
+	base := Fixture(printing("01050", "Hedge Fund"), printing("01051", "Sure Gamble"))
+
+	tests := []struct {
+		name    string
+		sources []CardSource
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "results in source order", sources: []CardSource{custom, base}, query: "hedge", want: []string{"Hedge Fund Manager", "Hedge Fund"}},
+		{name: "no results", sources: []CardSource{custom, base}, query: "account siphon", want: nil},
+		{name: "failing source first", sources: []CardSource{broken{}, base}, query: "gamble", want: []string{"Sure Gamble"}},
+		{name: "failing source last", sources: []CardSource{custom, broken{}}, query: "hedge", want: []string{"Hedge Fund Manager"}},
+		{name: "every source fails", sources: []CardSource{broken{}, broken{}}, query: "hedge", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Chain(tt.sources...).Search(tt.query)
+			if tt.wantErr {
+				if !errors.Is(err, errBroken) {
+					t.Fatalf("expected %s, got %v", errBroken, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(titles(got), tt.want) {
+				t.Errorf("got %q, want %q", titles(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestChainAll(t *testing.T) {
+
+	custom := Fixture(printing("c1", "Custom Card"))
+	base := Fixture(printing("01050", "Hedge Fund"), printing("01051", "Sure Gamble"))
+
+	tests := []struct {
+		name    string
+		sources []CardSource
+		want    []string
+		wantErr bool
+	}{
+		{name: "every source", sources: []CardSource{custom, base}, want: []string{"c1", "01050", "01051"}},
+		{name: "skips failing source", sources: []CardSource{base, broken{}}, want: []string{"01050", "01051"}},
+		{name: "every source fails", sources: []CardSource{broken{}, broken{}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Chain(tt.sources...).All()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", printingIDs(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(printingIDs(got), tt.want) {
+				t.Errorf("got %q, want %q", printingIDs(got), tt.want)
+			}
+		})
+	}
+}
+
+// counting counts the calls to All and fails the first ones
+type counting struct {
+	CardSource
+	calls, failures int
+}
+
+func (src *counting) All() ([]*nrdb.Printing, error) {
+	src.calls++
+	if src.calls <= src.failures {
+		return nil, errBroken
+	}
+	return src.CardSource.All()
+}
+
+func TestRememberAll(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		failures  int
+		lookups   int
+		wantCalls int
+	}{
+		{name: "lists once", lookups: 3, wantCalls: 1},
+		{name: "retries after a failure", failures: 1, lookups: 3, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &counting{CardSource: Fixture(printing("01050", "Hedge Fund")), failures: tt.failures}
+			src := RememberAll(inner)
+
+			for i := 0; i < tt.lookups; i++ {
+				got, err := src.All()
+				if i < tt.failures {
+					if err == nil {
+						t.Fatalf("lookup %d: expected an error", i)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("lookup %d: %s", i, err)
+				}
+				if !slices.Equal(printingIDs(got), []string{"01050"}) {
+					t.Fatalf("lookup %d: got %q", i, printingIDs(got))
+				}
+			}
+
+			if inner.calls != tt.wantCalls {
+				t.Errorf("got %d calls to All, want %d", inner.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFallback(t *testing.T) {
+
+	cached := Fixture(printing("01050", "Hedge Fund"))
+
+	src := Fallback(broken{}, cached)
+
+	found, err := src.ByPrintingID("01050")
+	if err != nil || found.ID != "01050" {
+		t.Errorf("got %v, %v from the fallback", found, err)
+	}
+
+	cards, err := src.Search("hedge")
+	if err != nil || !slices.Equal(titles(cards), []string{"Hedge Fund"}) {
+		t.Errorf("got %q, %v from the fallback", titles(cards), err)
+	}
+
+	// the fallback finding nothing keeps the original failure
+	if _, err := src.Search("sure gamble"); !errors.Is(err, errBroken) {
+		t.Errorf("got %v, want %s", err, errBroken)
+	}
+	if _, err := src.ByPrintingID("01051"); !errors.Is(err, errBroken) {
+		t.Errorf("got %v, want %s", err, errBroken)
+	}
+
+	// a working source is never second-guessed
+	cards, err = Fallback(Fixture(printing("c1", "Hedge Fund (alt)")), cached).Search("hedge")
+	if err != nil || !slices.Equal(titles(cards), []string{"Hedge Fund (alt)"}) {
+		t.Errorf("got %q, %v, want the first source only", titles(cards), err)
+	}
+}
+
+func TestChainWarnsAboutFailingSources(t *testing.T) {
+
+	hedgeFund := printing("01050", "Hedge Fund")
+	hedgeFund.Attributes.CardSetID = "core"
+
+	var warnings []error
+	ch := chain{
+		sources: []CardSource{broken{}, Fixture(hedgeFund)},
+		warn:    func(err error) { warnings = append(warnings, err) },
+	}
+
+	cards, err := ch.Search("hedge")
+	if err != nil || !slices.Equal(titles(cards), []string{"Hedge Fund"}) {
+		t.Errorf("search got %q, %v", titles(cards), err)
+	}
+	printings, err := ch.All()
+	if err != nil || !slices.Equal(printingIDs(printings), []string{"01050"}) {
+		t.Errorf("all got %q, %v", printingIDs(printings), err)
+	}
+	printings, err = InSet(ch, "core")
+	if err != nil || !slices.Equal(printingIDs(printings), []string{"01050"}) {
+		t.Errorf("set got %q, %v", printingIDs(printings), err)
+	}
+
+	if len(warnings) != 3 {
+		t.Fatalf("got %d warnings %v, want one for each lookup", len(warnings), warnings)
+	}
+	for _, err := range warnings {
+		if !errors.Is(err, errBroken) {
+			t.Errorf("got warning %v, want %s", err, errBroken)
+		}
+	}
+
+	// nothing to warn about when there are no results at all
+	warnings = nil
+	if _, err := InSet(chain{sources: []CardSource{broken{}}, warn: ch.warn}, "core"); !errors.Is(err, errBroken) {
+		t.Errorf("got %v, want %s", err, errBroken)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("got warnings %v for a failed lookup", warnings)
+	}
+}
@@ -0,0 +1,55 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mangofeet/nrdb-go"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Trackers is a source of tracker components, like a credit or click
+// tracker. They aren't cards on NRDB, any name is made into one.
+func Trackers() CardSource {
+	return trackers{}
+}
+
+type trackers struct{}
+
+// ByPrintingID takes the tracker name as ID, there are no others
+func (trackers) ByPrintingID(name string) (*nrdb.Printing, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("tracker name is empty")
+	}
+
+	return &nrdb.Printing{
+		Document: nrdb.Document[nrdb.PrintingAttributes, nrdb.PrintingRelationships]{
+			ID: "0",
+			Attributes: &nrdb.PrintingAttributes{
+				CardAttributes: nrdb.CardAttributes{
+					Title:         cases.Title(language.English).String(name),
+					FactionID:     name, // allows auto coloring things
+					StrippedTitle: fmt.Sprintf("%s tracker", name),
+				},
+				CardSetID:     "components",
+				PositionInSet: 0,
+			},
+		},
+	}, nil
+}
+
+func (src trackers) Search(query string) ([]*nrdb.Card, error) {
+	printing, err := src.ByPrintingID(query)
+	if err != nil {
+		return nil, err
+	}
+	return Cards([]*nrdb.Printing{printing}), nil
+}
+
+// All is empty, trackers only exist once they are asked for
+func (trackers) All() ([]*nrdb.Printing, error) {
+	return nil, nil
+}
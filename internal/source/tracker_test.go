@@ -0,0 +1,27 @@
+package source
+
+import "testing"
+
+func TestTrackers(t *testing.T) {
+
+	got, err := Trackers().ByPrintingID("credits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := got.Attributes
+	if attrs.Title != "Credits" || attrs.StrippedTitle != "credits tracker" || attrs.FactionID != "credits" || attrs.CardSetID != "components" {
+		t.Errorf("got %+v", attrs.CardAttributes)
+	}
+
+	cards, err := Trackers().Search("clicks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].LatestPrintingID() != "0" || cards[0].Attributes.Title != "Clicks" {
+		t.Errorf("got %d cards for a tracker search", len(cards))
+	}
+
+	if _, err := Trackers().ByPrintingID("  "); err == nil {
+		t.Error("expected a blank tracker name to fail")
+	}
+}
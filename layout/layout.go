@@ -0,0 +1,70 @@
+// Package layout holds the physical measurements of a card and the
+// units everything is drawn in.
+//
+// Art and frames are always drawn on a canvas of design units, one
+// unit being 1/1200 of an inch, so every card has the same
+// composition. The output DPI only changes how finely that canvas is
+// rasterized.
+package layout
+
+import "github.com/tdewolff/canvas"
+
+// DesignDPI is the number of design units per inch
+const DesignDPI = 1200.0
+
+const (
+	CardWidthMM  = 63.0
+	CardHeightMM = 88.0
+
+	// BleedMM is the art past the cut line on each side, 1/8"
+	BleedMM = 3.175
+
+	// SafeMarginMM is how far inside the cut line text and symbols
+	// are kept
+	SafeMarginMM = 2.54
+
+	// CornerRadiusMM is the rounding of a cut card's corners, 1/8"
+	CornerRadiusMM = 3.175
+
+	CanvasWidthMM  = CardWidthMM + BleedMM*2
+	CanvasHeightMM = CardHeightMM + BleedMM*2
+	SafeWidthMM    = CardWidthMM - SafeMarginMM*2
+	SafeHeightMM   = CardHeightMM - SafeMarginMM*2
+)
+
+// sizes in design units
+var (
+	CanvasWidth  = Units(CanvasWidthMM)
+	CanvasHeight = Units(CanvasHeightMM)
+	CardWidth    = Units(CardWidthMM)
+	CardHeight   = Units(CardHeightMM)
+	SafeWidth    = Units(SafeWidthMM)
+	SafeHeight   = Units(SafeHeightMM)
+	Bleed        = Units(BleedMM)
+	CornerRadius = Units(CornerRadiusMM)
+)
+
+// DPI is the resolution raster output is rendered at
+var DPI = DesignDPI
+
+// Units converts millimetres to design units
+func Units(mm float64) float64 {
+	return mm / 25.4 * DesignDPI
+}
+
+// MM converts design units to millimetres
+func MM(units float64) float64 {
+	return units / DesignDPI * 25.4
+}
+
+// Resolution is the number of output pixels per design unit
+func Resolution() canvas.Resolution {
+	return canvas.DPMM(DPI / DesignDPI)
+}
+
+// ImageMatrix places an image made by Rasterize back onto a design
+// unit canvas
+func ImageMatrix() canvas.Matrix {
+	scale := DesignDPI / DPI
+	return canvas.Identity.Scale(scale, scale)
+}
@@ -0,0 +1,267 @@
+package layout
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+)
+
+// BandPixels is the most pixels a band of a tiled raster holds, 64MB
+// of RGBA. Rasters bigger than this are only ever drawn a band of rows
+// at a time, at the default DPI a whole card fits in one band.
+var BandPixels = 16 << 20
+
+// RasterSize is the size of the raster of cnv at the output DPI
+func RasterSize(cnv *canvas.Canvas) image.Point {
+	dpmm := Resolution().DPMM()
+	return image.Pt(int(cnv.W*dpmm+0.5), int(cnv.H*dpmm+0.5))
+}
+
+// Bands splits the rows of a raster of the given size into bands of at
+// most BandPixels pixels, top to bottom
+func Bands(size image.Point) []image.Rectangle {
+	rows := max(1, BandPixels/max(1, size.X))
+
+	var bands []image.Rectangle
+	for y := 0; y < size.Y; y += rows {
+		bands = append(bands, image.Rect(0, y, size.X, min(y+rows, size.Y)))
+	}
+	return bands
+}
+
+// Rasterize draws a design unit canvas to an image at the output DPI
+func Rasterize(cnv *canvas.Canvas) *image.RGBA {
+	size := RasterSize(cnv)
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	for _, band := range Bands(size) {
+		cnv.RenderTo(NewRasterizer(img.SubImage(band).(*image.RGBA), size.Y, Resolution()))
+	}
+	return img
+}
+
+// RasterizeRows draws the rows of the raster of cnv within rows, which
+// span its whole width
+func RasterizeRows(cnv *canvas.Canvas, rows image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(rows)
+	cnv.RenderTo(NewRasterizer(img, RasterSize(cnv).Y, Resolution()))
+	return img
+}
+
+// RasterizeBands draws cnv a band at a time, each band is handed to fn
+// before the next one is drawn
+func RasterizeBands(cnv *canvas.Canvas, fn func(band *image.RGBA) error) error {
+	for _, band := range Bands(RasterSize(cnv)) {
+		if err := fn(RasterizeRows(cnv, band)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RasterizeTiled is Rasterize for a canvas that goes back on a card as
+// an image, when it's bigger than a band it's drawn as it's needed
+func RasterizeTiled(cnv *canvas.Canvas) image.Image {
+	return Tile(RasterSize(cnv), func(dst *image.RGBA) {
+		cnv.RenderTo(NewRasterizer(dst, RasterSize(cnv).Y, Resolution()))
+	})
+}
+
+// TiledImage is a raster of a whole canvas that is drawn a few rows at a
+// time whenever they're needed, so it's never all in memory at once.
+// It's placed with ImageMatrix like any other raster.
+type TiledImage struct {
+	size image.Point
+	draw func(dst *image.RGBA)
+
+	// the band At last looked at
+	mu     sync.Mutex
+	cached *image.RGBA
+}
+
+// Tile makes an image of the given size whose rows are drawn by draw on
+// a transparent dst covering them. When the image fits in one band
+// it's drawn straight away and returned as an *image.RGBA.
+func Tile(size image.Point, draw func(dst *image.RGBA)) image.Image {
+	if size.X*size.Y <= BandPixels {
+		img := image.NewRGBA(image.Rectangle{Max: size})
+		draw(img)
+		return img
+	}
+	return &TiledImage{size: size, draw: draw}
+}
+
+func (img *TiledImage) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (img *TiledImage) Bounds() image.Rectangle {
+	return image.Rectangle{Max: img.size}
+}
+
+// At draws the whole band around y, reading pixels any other way than
+// row after row is very slow
+func (img *TiledImage) At(x, y int) color.Color {
+	if !image.Pt(x, y).In(img.Bounds()) {
+		return color.RGBA{}
+	}
+
+	img.mu.Lock()
+	defer img.mu.Unlock()
+
+	if img.cached == nil || !image.Pt(x, y).In(img.cached.Rect) {
+		rows := max(1, BandPixels/img.size.X)
+		top := y / rows * rows
+		img.cached = img.Rows(top, min(top+rows, img.size.Y))
+	}
+	return img.cached.RGBAAt(x, y)
+}
+
+// Rows draws the rows from top up to bottom
+func (img *TiledImage) Rows(top, bottom int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, top, img.size.X, bottom))
+	img.draw(dst)
+	return dst
+}
+
+// Rows returns the rows of img from top up to bottom, only drawing
+// those of a TiledImage
+func Rows(img image.Image, top, bottom int) *image.RGBA {
+	bounds := img.Bounds()
+	rows := image.Rect(bounds.Min.X, top, bounds.Max.X, bottom)
+
+	switch img := img.(type) {
+	case *TiledImage:
+		return img.Rows(top, bottom)
+	case *image.RGBA:
+		return img.SubImage(rows).(*image.RGBA)
+	}
+
+	dst := image.NewRGBA(rows)
+	draw.Draw(dst, rows, img, rows.Min, draw.Src)
+	return dst
+}
+
+// Rasterizer draws a canvas on some rows of its raster. Paths outside
+// the rows are skipped and only the rows of an image under them are
+// read, so drawing a TiledImage never draws all of it.
+type Rasterizer struct {
+	ras        *rasterizer.Rasterizer
+	dst        *image.RGBA
+	resolution canvas.Resolution
+	height     int
+
+	// the rows in design units, and the move that puts them at the
+	// bottom of ras
+	bottom, top float64
+	offset      canvas.Matrix
+}
+
+// NewRasterizer draws on dst, which spans some rows of a raster height
+// pixels high at resolution res
+func NewRasterizer(dst *image.RGBA, height int, res canvas.Resolution) *Rasterizer {
+	// the rasterizer expects its image to start at 0, 0
+	img := &image.RGBA{
+		Pix:    dst.Pix,
+		Stride: dst.Stride,
+		Rect:   image.Rectangle{Max: dst.Rect.Size()},
+	}
+
+	dpmm := res.DPMM()
+	bottom := float64(height-dst.Rect.Max.Y) / dpmm
+
+	return &Rasterizer{
+		ras:        rasterizer.FromImage(img, res, canvas.DefaultColorSpace),
+		dst:        dst,
+		resolution: res,
+		height:     height,
+		bottom:     bottom,
+		top:        float64(height-dst.Rect.Min.Y) / dpmm,
+		offset:     canvas.Identity.Translate(0, -bottom),
+	}
+}
+
+func (r *Rasterizer) Size() (float64, float64) {
+	dpmm := r.resolution.DPMM()
+	return float64(r.dst.Rect.Dx()) / dpmm, float64(r.height) / dpmm
+}
+
+func (r *Rasterizer) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	bounds := path.Bounds()
+	if style.HasStroke() {
+		// miter joins can reach out twice the stroke width
+		pad := style.StrokeWidth * 2
+		bounds = canvas.Rect{X: bounds.X - pad, Y: bounds.Y - pad, W: bounds.W + pad*2, H: bounds.H + pad*2}
+	}
+	bounds = bounds.Transform(m)
+
+	// the rasterizer pads everything by 2 pixels
+	pad := 2 / r.resolution.DPMM()
+	if r.top+pad < bounds.Y || bounds.Y+bounds.H < r.bottom-pad {
+		return
+	}
+
+	r.ras.RenderPath(path, style, r.offset.Mul(m))
+}
+
+func (r *Rasterizer) RenderText(text *canvas.Text, m canvas.Matrix) {
+	// glyphs are lined up on the pixels of the whole raster, or a
+	// line of text split over two bands won't meet up
+	text.RenderAsPath(r, m, r.resolution)
+}
+
+func (r *Rasterizer) RenderImage(img image.Image, m canvas.Matrix) {
+	if m[0][1] != 0 || m[1][0] != 0 || m[1][1] <= 0 {
+		// rotated and flipped images are drawn whole
+		r.ras.RenderImage(img, r.offset.Mul(m))
+		return
+	}
+
+	// images are drawn upwards from the origin of m, in pixels of
+	// the image scaled by m
+	dpmm := r.resolution.DPMM()
+	bounds := img.Bounds()
+	height := bounds.Dy()
+	scale := m[1][1] * dpmm
+
+	// a raster lined up with the pixels is copied
+	x, y := m[0][2]*dpmm, float64(r.height)-m[1][2]*dpmm-float64(height)
+	if m[0][0]*dpmm == 1 && scale == 1 && x == math.Trunc(x) && y == math.Trunc(y) {
+		top := max(0, r.dst.Rect.Min.Y-int(y))
+		bottom := min(height, r.dst.Rect.Max.Y-int(y))
+		if top >= bottom {
+			return
+		}
+		rows := Rows(img, bounds.Min.Y+top, bounds.Min.Y+bottom)
+		draw.Draw(r.dst, r.dst.Rect, rows, r.dst.Rect.Min.Add(bounds.Min).Sub(image.Pt(int(x), int(y))), draw.Over)
+		return
+	}
+
+	// the rows of img under dst, with enough around them for the
+	// resampling to be the same as for the whole image
+	margin := int(math.Ceil(2*math.Max(1, 1/scale))) + 1
+	top := max(0, int(math.Floor(float64(height)-(r.top-m[1][2])/m[1][1]))-margin)
+	bottom := min(height, int(math.Ceil(float64(height)-(r.bottom-m[1][2])/m[1][1]))+margin)
+	if top >= bottom {
+		return
+	}
+
+	// too many rows are split over two halves of dst
+	if (bottom-top)*bounds.Dx() > BandPixels && r.dst.Rect.Dy() > 1 {
+		mid := (r.dst.Rect.Min.Y + r.dst.Rect.Max.Y) / 2
+		for _, half := range []image.Rectangle{
+			image.Rect(r.dst.Rect.Min.X, r.dst.Rect.Min.Y, r.dst.Rect.Max.X, mid),
+			image.Rect(r.dst.Rect.Min.X, mid, r.dst.Rect.Max.X, r.dst.Rect.Max.Y),
+		} {
+			NewRasterizer(r.dst.SubImage(half).(*image.RGBA), r.height, r.resolution).RenderImage(img, m)
+		}
+		return
+	}
+
+	rows := Rows(img, bounds.Min.Y+top, bounds.Min.Y+bottom)
+	r.ras.RenderImage(rows, r.offset.Mul(m).Translate(0, float64(height-bottom)))
+}
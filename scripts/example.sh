@@ -1,11 +1,13 @@
 #!/usr/bin/env bash
 
+# also write a cropped preview of each card to output/cropped
+preview=(--crop trim --keep-full-bleed)
 
-./netrunner-alt-gen netwalker hedge fund
+./netrunner-alt-gen netwalker hedge fund "${preview[@]}"
                     
 flavor_sure_gamble='"I would suggest variable text box size.<BR>Wasted space on stuff like Sure Gambles is something that'"'"'s always bothered me for alt arts."'
 
-./netrunner-alt-gen netwalker sure gamble \
+./netrunner-alt-gen netwalker sure gamble "${preview[@]}" \
                     --make-back \
                     --text-box-height 26 \
                     --flavor "${flavor_sure_gamble}" \
@@ -22,16 +24,5 @@ flavor_sure_gamble='"I would suggest variable text box size.<BR>Wasted space on
 
 
 # skip flavor on FFG cards as a courtesy
-./netrunner-alt-gen netwalker diversion of funds \
+./netrunner-alt-gen netwalker diversion of funds "${preview[@]}" \
                     --skip-flavor
-
-# get a cropped preview
-(
-    cd output
-    mkdir -p cropped
-    for f in *
-    do
-        echo "converting $f"
-        convert $f -crop 2976x4152+144+149 cropped/$f
-    done
-)